@@ -0,0 +1,206 @@
+package mobone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Batch queues ModelStore operations, possibly of different stores of the same pool
+// and TransactionManager, and sends them in one round trip.
+// Outside a transaction PostgreSQL runs the whole batch implicitly as one, so a failed
+// operation rolls back the others too.
+type Batch struct {
+	batch pgx.Batch
+	items []batchItem
+	store *ModelStore
+	err   error
+}
+
+type batchItem struct {
+	read func(br pgx.BatchResults) error
+}
+
+type BatchResult struct {
+	RowsAffected int64
+	Err          error
+}
+
+type BatchGetResult struct {
+	Found bool
+	Err   error
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+func (b *Batch) Create(s *ModelStore, m CreateModelI) *BatchResult {
+	result := &BatchResult{}
+	query, args, returningFieldPointers, err := s.buildCreate(m)
+	b.queueReturning(s, result, query, args, returningFieldPointers, false, err)
+	return result
+}
+
+func (b *Batch) Update(s *ModelStore, m UpdateModelI) *BatchResult {
+	result := &BatchResult{}
+	query, args, returningFieldPointers, err := s.buildUpdate(m)
+	b.queueReturning(s, result, query, args, returningFieldPointers, s.RequireRowsAffected, err)
+	return result
+}
+
+func (b *Batch) Delete(s *ModelStore, m DeleteModelI) *BatchResult {
+	result := &BatchResult{}
+	query, args, returningFieldPointers, err := s.buildDelete(m)
+	b.queueReturning(s, result, query, args, returningFieldPointers, s.RequireRowsAffected, err)
+	return result
+}
+
+func (b *Batch) Get(s *ModelStore, m GetModelI) *BatchGetResult {
+	result := &BatchGetResult{}
+
+	query, args, colFieldPointers, err := s.buildGet(m)
+	if err != nil {
+		result.Err = err
+		b.setErr(err)
+		return result
+	}
+
+	result.Err = b.queue(s, query, args, func(br pgx.BatchResults) error {
+		err := br.QueryRow().Scan(colFieldPointers...)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			result.Err = fmt.Errorf("fail to query: %w", classifyError(err))
+			return result.Err
+		}
+
+		result.Found = true
+		return nil
+	})
+
+	return result
+}
+
+// Send sends the queued operations on the connection of the first queued store and fills the results.
+// It returns the first error of any operation, every result carries its own one.
+// The batch is empty afterwards.
+func (b *Batch) Send(ctx context.Context) error {
+	defer b.reset()
+
+	if len(b.items) == 0 {
+		return b.err
+	}
+
+	con, err := b.store.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	br := con.SendBatch(ctx, &b.batch)
+
+	for _, item := range b.items {
+		if err = item.read(br); err != nil {
+			b.setErr(err)
+		}
+	}
+
+	if err = br.Close(); err != nil {
+		b.setErr(fmt.Errorf("fail to send batch: %w", classifyError(err)))
+	}
+
+	return b.err
+}
+
+// queueReturning queues a write, requireRowsAffected sets ErrNotFound on the result when no row matched,
+// like ModelStore.RequireRowsAffected does for Update and Delete.
+func (b *Batch) queueReturning(s *ModelStore, result *BatchResult, query string, args []any, returningFieldPointers []any, requireRowsAffected bool, err error) {
+	if err != nil {
+		result.Err = fmt.Errorf("fail to build query: %w", err)
+		b.setErr(result.Err)
+		return
+	}
+
+	if len(returningFieldPointers) > 0 {
+		result.Err = b.queue(s, query, args, func(br pgx.BatchResults) error {
+			rows, err := br.Query()
+			if err != nil {
+				result.Err = fmt.Errorf("fail to query: %w", classifyError(err))
+				return result.Err
+			}
+
+			result.RowsAffected, result.Err = scanFirstRow(rows, returningFieldPointers)
+			if result.Err == nil && requireRowsAffected && result.RowsAffected == 0 {
+				result.Err = ErrNotFound
+			}
+			return result.Err
+		})
+		return
+	}
+
+	result.Err = b.queue(s, query, args, func(br pgx.BatchResults) error {
+		tag, err := br.Exec()
+		if err != nil {
+			result.Err = fmt.Errorf("fail to exec: %w", classifyError(err))
+			return result.Err
+		}
+
+		result.RowsAffected = tag.RowsAffected()
+		if requireRowsAffected && result.RowsAffected == 0 {
+			result.Err = ErrNotFound
+		}
+		return result.Err
+	})
+}
+
+// queue rejects a store that could resolve to another pool or transaction than the first queued one,
+// because the whole batch is sent over one connection.
+func (b *Batch) queue(s *ModelStore, query string, args []any, read func(br pgx.BatchResults) error) error {
+	if b.store == nil {
+		b.store = s
+	} else if s != b.store && (s.Con != b.store.Con || !sameConnectionGetter(s.TransactionManager, b.store.TransactionManager)) {
+		b.setErr(ErrBatchStoreMismatch)
+		return ErrBatchStoreMismatch
+	}
+
+	b.batch.Queue(query, args...)
+	b.items = append(b.items, batchItem{read: read})
+
+	return nil
+}
+
+// reset empties the batch after Send, so it can be reused without replaying old operations.
+func (b *Batch) reset() {
+	b.batch = pgx.Batch{}
+	b.items = nil
+	b.store = nil
+	b.err = nil
+}
+
+// sameConnectionGetter compares transaction managers without panicking on values that are not comparable,
+// those are never considered the same.
+func sameConnectionGetter(a, b connectionGetterI) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if !reflect.ValueOf(a).Comparable() || !reflect.ValueOf(b).Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+func (b *Batch) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,517 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// maxQueryParams is the limit of bind parameters in one PostgreSQL statement
+const maxQueryParams = 65535
+
+var defaultValueExpr = squirrel.Expr("DEFAULT")
+
+// CreateMany inserts ms with multi-row INSERT statements, chunked to stay under the bind parameter limit.
+// Columns are the union of CreateColumnMap keys, missing ones are inserted as DEFAULT.
+// RETURNING values of the first model's ReturningColumnMap keys are scanned back into each model in order.
+// Chunks are separate statements, call it inside TxFn to make the whole insert atomic.
+func (s *ModelStore) CreateMany(ctx context.Context, ms []CreateModelI) error {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	columnMaps := make([]map[string]any, len(ms))
+	for i, m := range ms {
+		columnMaps[i] = m.CreateColumnMap()
+	}
+
+	colNames := unionColumnNames(columnMaps)
+	if len(colNames) == 0 {
+		return fmt.Errorf("no columns")
+	}
+
+	returningColumnNames, _ := columnNamesAndPointers(ms[0].ReturningColumnMap())
+
+	rows := make([][]any, len(ms))
+	rowParamCounts := make([]int, len(ms))
+	for i, columnMap := range columnMaps {
+		rows[i], rowParamCounts[i], err = insertRowValues(colNames, columnMap)
+		if err != nil {
+			return fmt.Errorf("fail to build query: %w", err)
+		}
+	}
+
+	for _, chunk := range chunkByParams(rowParamCounts, maxQueryParams) {
+		queryBuilder := s.QB.Insert(s.TableName).Columns(colNames...)
+		for _, row := range rows[chunk[0]:chunk[1]] {
+			queryBuilder = queryBuilder.Values(row...)
+		}
+
+		if len(returningColumnNames) > 0 {
+			queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(returningColumnNames, ","))
+		}
+
+		query, args, err := queryBuilder.ToSql()
+		if err != nil {
+			return fmt.Errorf("fail to build query: %w", err)
+		}
+
+		if len(returningColumnNames) == 0 {
+			_, err = con.Exec(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("fail to exec: %w", classifyError(err))
+			}
+			continue
+		}
+
+		err = queryReturningInto(ctx, con, query, args, returningColumnNames, ms[chunk[0]:chunk[1]])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryReturningInto scans the RETURNING rows of a multi-row statement into ms, row by row.
+func queryReturningInto[M interface{ ReturningColumnMap() map[string]any }](ctx context.Context, con ConnectionI, query string, args []any, returningColumnNames []string, ms []M) error {
+	rows, err := con.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	i := 0
+	for rows.Next() {
+		if i >= len(ms) {
+			return fmt.Errorf("more returned rows than models")
+		}
+
+		returningColumnMap := ms[i].ReturningColumnMap()
+		fieldPointers := make([]any, 0, len(returningColumnNames))
+		for _, colName := range returningColumnNames {
+			fieldPointer, ok := returningColumnMap[colName]
+			if !ok {
+				return fmt.Errorf("returning column %q is missing in model %d", colName, i)
+			}
+			fieldPointers = append(fieldPointers, fieldPointer)
+		}
+
+		err = rows.Scan(fieldPointers...)
+		if err != nil {
+			return fmt.Errorf("fail to scan: %w", err)
+		}
+		i++
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err: %w", classifyError(err))
+	}
+
+	return nil
+}
+
+// unionColumnNames returns the sorted union of the keys of columnMaps.
+func unionColumnNames(columnMaps []map[string]any) []string {
+	colSet := make(map[string]struct{})
+	for _, columnMap := range columnMaps {
+		for k := range columnMap {
+			colSet[k] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(colSet))
+	for k := range colSet {
+		result = append(result, k)
+	}
+	slices.Sort(result)
+
+	return result
+}
+
+// insertRowValues returns the VALUES row for colNames with DEFAULT for missing columns,
+// and the number of bind parameters the row takes.
+func insertRowValues(colNames []string, columnMap map[string]any) ([]any, int, error) {
+	row := make([]any, len(colNames))
+	paramCount := 0
+	for i, colName := range colNames {
+		v, ok := columnMap[colName]
+		if !ok {
+			row[i] = defaultValueExpr
+			continue
+		}
+
+		row[i] = v
+
+		if sqlizer, ok := v.(squirrel.Sqlizer); ok {
+			_, args, err := sqlizer.ToSql()
+			if err != nil {
+				return nil, 0, err
+			}
+			paramCount += len(args)
+		} else {
+			paramCount++
+		}
+	}
+
+	return row, paramCount, nil
+}
+
+// chunkByParams splits rows into [start, end) ranges, each taking at most maxParams bind parameters.
+func chunkByParams(rowParamCounts []int, maxParams int) [][2]int {
+	result := make([][2]int, 0, 1)
+	start, params := 0, 0
+	for i, rowParams := range rowParamCounts {
+		if i > start && params+rowParams > maxParams {
+			result = append(result, [2]int{start, i})
+			start, params = i, 0
+		}
+		params += rowParams
+	}
+	if start < len(rowParamCounts) {
+		result = append(result, [2]int{start, len(rowParamCounts)})
+	}
+
+	return result
+}
+
+type UpsertBatchResult struct {
+	Inserted int64
+	Updated  int64
+	Skipped  int64
+}
+
+// upsertGroup is a set of models that share one INSERT ... ON CONFLICT statement shape.
+type upsertGroup struct {
+	colNames       []string
+	onConflictSQL  string
+	onConflictArgs []any
+	rows           [][]any
+	rowParamCounts []int
+}
+
+// UpdateOrCreateMany upserts ms with chunked multi-row INSERT ... ON CONFLICT ... DO UPDATE statements.
+// Update values equal to the created ones become EXCLUDED references, so
+// squirrel.Expr("contact || ?", v) turns into contact || EXCLUDED.contact.
+// Models whose update values can not be expressed that way are rejected.
+// Models are grouped by their column sets and conflict clause, one result is returned per executed statement.
+// The same conflict key must not repeat within ms, PostgreSQL can not update a row twice in one statement.
+func (s *ModelStore) UpdateOrCreateMany(ctx context.Context, ms []UpdateCreateModelI) ([]UpsertBatchResult, error) {
+	if len(ms) == 0 {
+		return nil, nil
+	}
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*upsertGroup, 0, 1)
+	groupsByKey := make(map[string][]*upsertGroup)
+	for i, m := range ms {
+		createColumnMap := m.CreateColumnMap()
+		colNames := unionColumnNames([]map[string]any{createColumnMap})
+		if len(colNames) == 0 {
+			return nil, fmt.Errorf("no columns in model %d", i)
+		}
+
+		onConflictSQL, onConflictArgs, err := batchOnConflictSQL(onConflictForModel(m), createColumnMap, m.UpdateColumnMap())
+		if err != nil {
+			return nil, fmt.Errorf("model %d: %w", i, err)
+		}
+
+		// groups of the same statement text are told apart by their typed arguments
+		groupKey := strings.Join(colNames, ",") + "\x00" + onConflictSQL
+		groupIndex := slices.IndexFunc(groupsByKey[groupKey], func(g *upsertGroup) bool {
+			return slices.EqualFunc(g.onConflictArgs, onConflictArgs, reflect.DeepEqual)
+		})
+		var group *upsertGroup
+		if groupIndex >= 0 {
+			group = groupsByKey[groupKey][groupIndex]
+		} else {
+			group = &upsertGroup{
+				colNames:       colNames,
+				onConflictSQL:  onConflictSQL,
+				onConflictArgs: onConflictArgs,
+			}
+			groupsByKey[groupKey] = append(groupsByKey[groupKey], group)
+			groups = append(groups, group)
+		}
+
+		row, rowParamCount, err := insertRowValues(colNames, createColumnMap)
+		if err != nil {
+			return nil, fmt.Errorf("fail to build query: %w", err)
+		}
+		group.rows = append(group.rows, row)
+		group.rowParamCounts = append(group.rowParamCounts, rowParamCount)
+	}
+
+	result := make([]UpsertBatchResult, 0, len(groups))
+	for _, group := range groups {
+		for _, chunk := range chunkByParams(group.rowParamCounts, maxQueryParams-len(group.onConflictArgs)) {
+			queryBuilder := s.QB.Insert(s.TableName + " as t").Columns(group.colNames...)
+			for _, row := range group.rows[chunk[0]:chunk[1]] {
+				queryBuilder = queryBuilder.Values(row...)
+			}
+			queryBuilder = queryBuilder.
+				Suffix(group.onConflictSQL, group.onConflictArgs...).
+				Suffix(`RETURNING (xmax = 0)`)
+
+			query, args, err := queryBuilder.ToSql()
+			if err != nil {
+				return result, fmt.Errorf("fail to build query: %w", err)
+			}
+
+			batchResult, err := queryUpsertBatchResult(ctx, con, query, args)
+			if err != nil {
+				return result, err
+			}
+			batchResult.Skipped = int64(chunk[1]-chunk[0]) - batchResult.Inserted - batchResult.Updated
+
+			result = append(result, batchResult)
+		}
+	}
+
+	return result, nil
+}
+
+func queryUpsertBatchResult(ctx context.Context, con ConnectionI, query string, args []any) (UpsertBatchResult, error) {
+	var result UpsertBatchResult
+
+	rows, err := con.Query(ctx, query, args...)
+	if err != nil {
+		return result, fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var inserted bool
+	for rows.Next() {
+		err = rows.Scan(&inserted)
+		if err != nil {
+			return result, fmt.Errorf("fail to scan: %w", err)
+		}
+
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return result, fmt.Errorf("rows.Err: %w", classifyError(err))
+	}
+
+	return result, nil
+}
+
+// batchOnConflictSQL builds the ON CONFLICT clause shared by all rows of a multi-row upsert.
+// Every update value has to be expressible through EXCLUDED, since rows can not carry own SET arguments.
+func batchOnConflictSQL(onConflict OnConflict, createColumnMap, updateColumnMap map[string]any) (string, []any, error) {
+	updateColumnNames := unionColumnNames([]map[string]any{updateColumnMap})
+	if len(updateColumnNames) == 0 {
+		return "", nil, fmt.Errorf("no update columns")
+	}
+
+	setClauses := make([]string, 0, len(updateColumnNames))
+	for _, colName := range updateColumnNames {
+		v := updateColumnMap[colName]
+
+		sqlizer, ok := v.(squirrel.Sqlizer)
+		if !ok {
+			if createV, ok := createColumnMap[colName]; !ok || !reflect.DeepEqual(createV, v) {
+				return "", nil, fmt.Errorf("update value of column %q differs from the created one, can not batch it", colName)
+			}
+			setClauses = append(setClauses, colName+` = EXCLUDED.`+colName)
+			continue
+		}
+
+		exprSQL, exprArgs, err := sqlizer.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+
+		if len(exprArgs) > 0 {
+			if len(exprArgs) > 1 || strings.Count(exprSQL, "?") != 1 {
+				return "", nil, fmt.Errorf("update expression of column %q must have at most one argument to batch it", colName)
+			}
+			if createV, ok := createColumnMap[colName]; !ok || !reflect.DeepEqual(createV, exprArgs[0]) {
+				return "", nil, fmt.Errorf("update expression argument of column %q differs from the created value, can not batch it", colName)
+			}
+			exprSQL = strings.Replace(exprSQL, "?", `EXCLUDED.`+colName, 1)
+		}
+
+		setClauses = append(setClauses, colName+` = `+exprSQL)
+	}
+
+	result := `ON CONFLICT ` + onConflict.targetSQL() + ` DO UPDATE SET ` + strings.Join(setClauses, ", ")
+
+	var args []any
+	if onConflict.UpdateWhere != nil {
+		whereSQL, whereArgs, err := onConflict.UpdateWhere.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+		result += ` WHERE ` + whereSQL
+		args = whereArgs
+	}
+
+	return result, args, nil
+}
+
+type UpdateManyResult struct {
+	Columns      []string
+	RowsAffected int64
+}
+
+// updateGroup is a set of models with the same PK and update column sets.
+type updateGroup struct {
+	pkColNames     []string
+	updateColNames []string
+	rows           [][]any
+}
+
+// UpdateMany updates ms with one UPDATE ... FROM (VALUES ...) statement per group of models
+// sharing the same UpdateColumnMap keys (chunked to stay under the bind parameter limit).
+// Values are cast to the table column types, squirrel expressions are not supported.
+// Returns rows affected per group, in the order groups first appear in ms.
+func (s *ModelStore) UpdateMany(ctx context.Context, ms []UpdateModelI) ([]UpdateManyResult, error) {
+	if len(ms) == 0 {
+		return nil, nil
+	}
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*updateGroup, 0, 1)
+	groupsByKey := make(map[string]*updateGroup)
+	for i, m := range ms {
+		pkColumnMap := m.PKColumnMap()
+		updateColumnMap := m.UpdateColumnMap()
+		pkColNames := unionColumnNames([]map[string]any{pkColumnMap})
+		updateColNames := unionColumnNames([]map[string]any{updateColumnMap})
+		if len(pkColNames) == 0 || len(updateColNames) == 0 {
+			return nil, fmt.Errorf("no columns in model %d", i)
+		}
+
+		groupKey := strings.Join(pkColNames, ",") + "\x00" + strings.Join(updateColNames, ",")
+		group, ok := groupsByKey[groupKey]
+		if !ok {
+			group = &updateGroup{
+				pkColNames:     pkColNames,
+				updateColNames: updateColNames,
+			}
+			groupsByKey[groupKey] = group
+			groups = append(groups, group)
+		}
+
+		row := make([]any, 0, len(pkColNames)+len(updateColNames))
+		for _, colName := range pkColNames {
+			row = append(row, pkColumnMap[colName])
+		}
+		for _, colName := range updateColNames {
+			v := updateColumnMap[colName]
+			if _, ok = v.(squirrel.Sqlizer); ok {
+				return nil, fmt.Errorf("column %q of model %d is an expression, UpdateMany needs plain values", colName, i)
+			}
+			row = append(row, v)
+		}
+		group.rows = append(group.rows, row)
+	}
+
+	colTypes, err := s.columnTypes(ctx, con)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]UpdateManyResult, 0, len(groups))
+	for _, group := range groups {
+		groupResult := UpdateManyResult{Columns: group.updateColNames}
+
+		colNames := append(slices.Clone(group.pkColNames), group.updateColNames...)
+
+		placeholders := make([]string, len(colNames))
+		for i, colName := range colNames {
+			colType, ok := colTypes[colName]
+			if !ok {
+				return result, fmt.Errorf("column %q not found in table %s", colName, s.TableName)
+			}
+			placeholders[i] = `?::` + colType
+		}
+		rowSQL := `(` + strings.Join(placeholders, ",") + `)`
+
+		rowsPerChunk := maxQueryParams / len(colNames)
+		for chunk := range slices.Chunk(group.rows, rowsPerChunk) {
+			valuesSQL := make([]string, len(chunk))
+			args := make([]any, 0, len(chunk)*len(colNames))
+			for i, row := range chunk {
+				valuesSQL[i] = rowSQL
+				args = append(args, row...)
+			}
+
+			queryBuilder := s.QB.Update(s.TableName+" AS t").
+				Prefix(`WITH v (`+strings.Join(colNames, ",")+`) AS (VALUES `+strings.Join(valuesSQL, ",")+`)`, args...).
+				From("v")
+			for _, colName := range group.updateColNames {
+				queryBuilder = queryBuilder.Set(colName, squirrel.Expr(`v.`+colName))
+			}
+			for _, colName := range group.pkColNames {
+				queryBuilder = queryBuilder.Where(`t.` + colName + ` = v.` + colName)
+			}
+
+			query, queryArgs, err := queryBuilder.ToSql()
+			if err != nil {
+				return result, fmt.Errorf("fail to build query: %w", err)
+			}
+
+			tag, err := con.Exec(ctx, query, queryArgs...)
+			if err != nil {
+				return result, fmt.Errorf("fail to exec: %w", classifyError(err))
+			}
+			groupResult.RowsAffected += tag.RowsAffected()
+		}
+
+		result = append(result, groupResult)
+	}
+
+	return result, nil
+}
+
+// columnTypes returns the SQL types of the table columns, used for explicit casts of VALUES lists.
+func (s *ModelStore) columnTypes(ctx context.Context, con ConnectionI) (map[string]string, error) {
+	rows, err := con.Query(ctx, `
+		select a.attname, format_type(a.atttypid, a.atttypmod)
+		from pg_attribute a
+		where a.attrelid = $1::regclass
+		  and a.attnum > 0
+		  and not a.attisdropped
+	`, s.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("fail to query column types: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	var colName, colType string
+	for rows.Next() {
+		err = rows.Scan(&colName, &colType)
+		if err != nil {
+			return nil, fmt.Errorf("fail to scan: %w", err)
+		}
+		result[colName] = colType
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", classifyError(err))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var cursorSeq atomic.Uint64
+
+// ForEachChunk runs the List query through a server-side cursor (DECLARE ... CURSOR, FETCH chunkSize)
+// and calls fn with every chunk, so huge results are never held on the client at once.
+// The cursor lives in its own transaction, or in a savepoint when ctx already has one,
+// and is closed when fn returns an error or ctx is cancelled.
+// WithTotalCount, OnlyCount and the cursors of ListPage are not supported.
+func (s *ModelStore) ForEachChunk(ctx context.Context, params ListParams, chunkSize int, itemConstructor func() ListModelI, fn func(ctx context.Context, items []ListModelI) error) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("chunk size must be positive")
+	}
+	if params.After != "" || params.Before != "" {
+		return fmt.Errorf("cursor pagination is not supported by ForEachChunk")
+	}
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	beginner, ok := con.(beginnerI)
+	if !ok {
+		return fmt.Errorf("connection does not support transactions")
+	}
+
+	listItemInstance := itemConstructor()
+
+	queryBuilder, colNames, err := s.buildListBase(params, listItemInstance)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := buildListSelect(queryBuilder, params, listItemInstance, colNames)
+	if err != nil {
+		return err
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	// rollback closes the cursor as well
+	defer rollbackDetached(ctx, tx, s.rollbackTimeout())
+
+	cursorName := "mobone_cursor_" + strconv.FormatUint(cursorSeq.Add(1), 10)
+
+	// DECLARE is a utility statement and can not have bind parameters, so args are sent inline
+	_, err = tx.Exec(ctx, `DECLARE `+cursorName+` NO SCROLL CURSOR FOR `+query, append([]any{pgx.QueryExecModeSimpleProtocol}, args...)...)
+	if err != nil {
+		return fmt.Errorf("fail to declare cursor: %w", classifyError(err))
+	}
+
+	fetchQuery := `FETCH ` + strconv.Itoa(chunkSize) + ` FROM ` + cursorName
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		items, err := fetchChunk(ctx, tx, fetchQuery, chunkSize, colNames, itemConstructor)
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			break
+		}
+
+		err = fn(ctx, items)
+		if err != nil {
+			return fmt.Errorf("chunk function: %w", err)
+		}
+
+		if len(items) < chunkSize {
+			break
+		}
+	}
+
+	_, err = tx.Exec(ctx, `CLOSE `+cursorName, pgx.QueryExecModeSimpleProtocol)
+	if err != nil {
+		return fmt.Errorf("fail to close cursor: %w", classifyError(err))
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("transaction commit: %w", err)
+	}
+
+	return nil
+}
+
+func fetchChunk(ctx context.Context, tx pgx.Tx, fetchQuery string, chunkSize int, colNames []string, itemConstructor func() ListModelI) ([]ListModelI, error) {
+	// simple protocol: cursor names are unique, preparing every FETCH would only fill the statement cache
+	rows, err := tx.Query(ctx, fetchQuery, pgx.QueryExecModeSimpleProtocol)
+	if err != nil {
+		return nil, fmt.Errorf("fail to fetch: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	items := make([]ListModelI, 0, chunkSize)
+	for rows.Next() {
+		m := itemConstructor()
+
+		err = rows.Scan(fieldPointersForColNames(m, colNames)...)
+		if err != nil {
+			return nil, fmt.Errorf("fail to scan: %w", err)
+		}
+
+		items = append(items, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", classifyError(err))
+	}
+
+	return items, nil
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type genField struct {
+	name      string // selector from the model, Base.Name for a field of an embedded struct
+	column    string
+	constName string
+	zeroCheck string
+	valueExpr string
+	imports   map[string]string // packages of zeroCheck by path
+	pk        bool
+	readonly  bool
+	returning bool
+	omitempty bool
+}
+
+type genType struct {
+	name   string
+	fields []genField
+}
+
+// generate loads the package of the input file and returns the generated model methods
+// for typeNames, or for every struct of the file with db tags when typeNames is empty.
+// Field types are resolved with go/types, so they may be declared in other files and packages.
+func generate(input string, typeNames []string) ([]byte, error) {
+	input, err := filepath.Abs(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// dependencies are type-checked from source without function bodies,
+	// export data of the go toolchain may be newer than go/packages can read
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedDeps |
+			packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir: filepath.Dir(input),
+		ParseFile: func(fset *token.FileSet, fileName string, src []byte) (*ast.File, error) {
+			file, err := parser.ParseFile(fset, fileName, src, parser.SkipObjectResolution)
+			if file != nil {
+				for _, decl := range file.Decls {
+					if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+						funcDecl.Body = nil
+					}
+				}
+			}
+			return file, err
+		},
+	}, "file="+input)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load package: %w", err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("fail to load package: %d packages found", len(pkgs))
+	}
+	pkg := pkgs[0]
+
+	// type errors are tolerated: the package may refer to the generated code being replaced
+	for _, pkgErr := range pkg.Errors {
+		if pkgErr.Kind != packages.TypeError {
+			return nil, fmt.Errorf("fail to load package: %w", pkgErr)
+		}
+	}
+
+	var file *ast.File
+	for i, fileName := range pkg.CompiledGoFiles {
+		if fileName == input && i < len(pkg.Syntax) {
+			file = pkg.Syntax[i]
+		}
+	}
+	if file == nil {
+		return nil, fmt.Errorf("fail to load package: %s is not in package %s", input, pkg.PkgPath)
+	}
+
+	genTypes, err := collectTypes(pkg, file, typeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := map[string]string{}
+	for _, t := range genTypes {
+		for _, f := range t.fields {
+			if !f.readonly {
+				for path, name := range f.imports {
+					imports[path] = name
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by mobone-gen. DO NOT EDIT.\n\npackage %s\n", pkg.Name)
+
+	if len(imports) > 0 {
+		fmt.Fprintf(&buf, "\nimport (\n")
+		for _, path := range slices.Sorted(maps.Keys(imports)) {
+			if name := imports[path]; name != filepath.Base(path) {
+				fmt.Fprintf(&buf, "%s %q\n", name, path)
+			} else {
+				fmt.Fprintf(&buf, "%q\n", path)
+			}
+		}
+		fmt.Fprintf(&buf, ")\n")
+	}
+
+	for _, t := range genTypes {
+		writeType(&buf, t)
+	}
+
+	result, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("fail to format generated code: %w", err)
+	}
+
+	return result, nil
+}
+
+func collectTypes(pkg *packages.Package, file *ast.File, typeNames []string) ([]genType, error) {
+	var result []genType
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if _, ok := typeSpec.Type.(*ast.StructType); !ok {
+				continue
+			}
+
+			requested := slices.Contains(typeNames, typeSpec.Name.Name)
+			if len(typeNames) > 0 && !requested {
+				continue
+			}
+
+			if typeSpec.TypeParams != nil {
+				return nil, fmt.Errorf("%s: generic types are not supported", typeSpec.Name.Name)
+			}
+
+			obj := pkg.TypesInfo.Defs[typeSpec.Name]
+			if obj == nil {
+				return nil, fmt.Errorf("%s: type is not resolved", typeSpec.Name.Name)
+			}
+
+			p := &structParser{pkg: pkg.Types}
+			t, err := p.parseStruct(typeSpec.Name.Name, obj.Type().Underlying().(*types.Struct))
+			if err != nil {
+				return nil, err
+			}
+
+			if len(t.fields) == 0 {
+				if requested {
+					return nil, fmt.Errorf("%s: no db tagged fields", t.name)
+				}
+				continue
+			}
+
+			result = append(result, t)
+		}
+	}
+
+	for _, typeName := range typeNames {
+		if !slices.ContainsFunc(result, func(t genType) bool { return t.name == typeName }) {
+			return nil, fmt.Errorf("%s: struct type not found", typeName)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no structs with db tags")
+	}
+
+	return result, nil
+}
+
+// structParser parses the struct types of the generated package pkg.
+type structParser struct {
+	pkg *types.Package
+}
+
+func (p *structParser) parseStruct(typeName string, structType *types.Struct) (genType, error) {
+	t := genType{name: typeName}
+
+	err := p.parseFields(&t, structType, "", map[string]bool{}, map[*types.Struct]bool{structType: true})
+
+	return t, err
+}
+
+// parseFields adds the tagged fields of structType, path is the selector prefix of an embedded struct.
+// Embedded structs without a tag are flattened, like TaggedModel does.
+func (p *structParser) parseFields(t *genType, structType *types.Struct, path string, columns map[string]bool, embedding map[*types.Struct]bool) error {
+	for i := range structType.NumFields() {
+		field := structType.Field(i)
+
+		tag, hasTag := reflect.StructTag(structType.Tag(i)).Lookup("db")
+		if tag == "-" {
+			continue
+		}
+
+		if !hasTag {
+			if !field.Embedded() {
+				continue
+			}
+
+			switch embedded := field.Type().Underlying().(type) {
+			case *types.Struct:
+				if embedding[embedded] {
+					return fmt.Errorf("%s: embedded %s embeds itself", t.name, field.Name())
+				}
+
+				embedding[embedded] = true
+				err := p.parseFields(t, embedded, path+field.Name()+".", columns, embedding)
+				if err != nil {
+					return err
+				}
+				delete(embedding, embedded)
+			case *types.Pointer:
+				if _, ok := embedded.Elem().Underlying().(*types.Struct); ok {
+					return fmt.Errorf("%s: embedded pointer %s is not supported, embed the struct or tag the field", t.name, field.Name())
+				}
+			}
+
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		if !field.Exported() {
+			return fmt.Errorf("%s.%s: tagged field is not exported", t.name, field.Name())
+		}
+		if name == "" {
+			return fmt.Errorf("%s.%s: empty column name", t.name, field.Name())
+		}
+		if columns[name] {
+			return fmt.Errorf("%s.%s: duplicate column %q", t.name, field.Name(), name)
+		}
+		columns[name] = true
+
+		f := genField{
+			name:      path + field.Name(),
+			column:    name,
+			constName: t.name + "Column" + field.Name(),
+		}
+
+		if slices.ContainsFunc(t.fields, func(tf genField) bool { return tf.constName == f.constName }) {
+			return fmt.Errorf("%s.%s: duplicate field name of embedded structs", t.name, f.name)
+		}
+
+		if options != "" {
+			for _, option := range strings.Split(options, ",") {
+				switch option {
+				case "pk":
+					f.pk = true
+				case "readonly":
+					f.readonly = true
+				case "returning":
+					f.returning = true
+				case "omitempty":
+					f.omitempty = true
+				default:
+					return fmt.Errorf("%s.%s: unknown db tag option %q", t.name, field.Name(), option)
+				}
+			}
+		}
+
+		err := p.setWriteExprs(&f, field.Type())
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.name, f.name, err)
+		}
+
+		t.fields = append(t.fields, f)
+	}
+
+	return nil
+}
+
+// setWriteExprs sets the condition to write the field (empty to always write it) and the written value:
+// pointer fields are written only when not nil, as their value. omitempty compares other fields
+// with their zero value, types that are not comparable must have an IsZero() bool method.
+func (p *structParser) setWriteExprs(f *genField, fieldType types.Type) error {
+	fieldExpr := "m." + f.name
+	f.valueExpr = fieldExpr
+
+	switch ft := fieldType.Underlying().(type) {
+	case *types.Basic:
+		if ft.Kind() == types.Invalid {
+			return fmt.Errorf("unknown type")
+		}
+	case *types.Pointer:
+		f.zeroCheck, f.valueExpr = fieldExpr+" != nil", "*"+fieldExpr
+		return nil
+	}
+
+	if !f.omitempty {
+		return nil
+	}
+
+	switch ft := fieldType.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case ft.Info()&types.IsString != 0:
+			f.zeroCheck = fieldExpr + ` != ""`
+		case ft.Info()&types.IsBoolean != 0:
+			f.zeroCheck = fieldExpr
+		case ft.Info()&types.IsNumeric != 0:
+			f.zeroCheck = fieldExpr + " != 0"
+		case ft.Kind() == types.UnsafePointer:
+			f.zeroCheck = fieldExpr + " != nil"
+		}
+	case *types.Slice, *types.Map:
+		f.zeroCheck = "len(" + fieldExpr + ") > 0"
+	case *types.Interface, *types.Chan, *types.Signature:
+		f.zeroCheck = fieldExpr + " != nil"
+	}
+	if f.zeroCheck != "" {
+		return nil
+	}
+
+	if hasIsZero(fieldType) {
+		f.zeroCheck = "!" + fieldExpr + ".IsZero()"
+		return nil
+	}
+
+	if !types.Comparable(fieldType) {
+		return fmt.Errorf("omitempty needs a comparable type or an IsZero() bool method, %s has neither", fieldType)
+	}
+
+	f.imports = map[string]string{}
+	typeString := types.TypeString(fieldType, func(pkg *types.Package) string {
+		if pkg == p.pkg {
+			return ""
+		}
+		f.imports[pkg.Path()] = pkg.Name()
+		return pkg.Name()
+	})
+	f.zeroCheck = fieldExpr + " != (" + typeString + "{})"
+
+	return nil
+}
+
+// hasIsZero reports whether t or *t has an IsZero() bool method, like time.Time.
+func hasIsZero(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, nil, "IsZero")
+	fn, ok := obj.(*types.Func)
+	if !ok || !fn.Exported() {
+		return false
+	}
+
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+
+	result, ok := sig.Results().At(0).Type().Underlying().(*types.Basic)
+	return ok && result.Kind() == types.Bool
+}
+
+func writeType(buf *bytes.Buffer, t genType) {
+	var pkFields, returningFields, writeFields []genField
+	for _, f := range t.fields {
+		if f.pk {
+			pkFields = append(pkFields, f)
+		}
+		if f.returning {
+			returningFields = append(returningFields, f)
+		}
+		if !f.readonly {
+			writeFields = append(writeFields, f)
+		}
+	}
+
+	// column name constants
+	fmt.Fprintf(buf, "\nconst (\n")
+	for _, f := range t.fields {
+		fmt.Fprintf(buf, "%s = %q\n", f.constName, f.column)
+	}
+	fmt.Fprintf(buf, ")\n")
+
+	// ListColumnMap
+	fmt.Fprintf(buf, "\nfunc (m *%s) ListColumnMap() map[string]any {\nreturn map[string]any{\n", t.name)
+	for _, f := range t.fields {
+		fmt.Fprintf(buf, "%s: &m.%s,\n", f.constName, f.name)
+	}
+	fmt.Fprintf(buf, "}\n}\n")
+
+	// DefaultSortColumns
+	fmt.Fprintf(buf, "\nfunc (m *%s) DefaultSortColumns() []string {\n", t.name)
+	if len(pkFields) == 0 {
+		fmt.Fprintf(buf, "return nil\n}\n")
+	} else {
+		constNames := make([]string, 0, len(pkFields))
+		for _, f := range pkFields {
+			constNames = append(constNames, f.constName)
+		}
+		fmt.Fprintf(buf, "return []string{%s}\n}\n", strings.Join(constNames, ", "))
+	}
+
+	// PKColumnMap
+	if len(pkFields) > 0 {
+		fmt.Fprintf(buf, "\nfunc (m *%s) PKColumnMap() map[string]any {\nreturn map[string]any{\n", t.name)
+		for _, f := range pkFields {
+			fmt.Fprintf(buf, "%s: m.%s,\n", f.constName, f.name)
+		}
+		fmt.Fprintf(buf, "}\n}\n")
+	}
+
+	// CreateColumnMap and UpdateColumnMap
+	writeColumnMapFunc(buf, t.name, "CreateColumnMap", writeFields)
+	writeColumnMapFunc(buf, t.name, "UpdateColumnMap", slices.DeleteFunc(slices.Clone(writeFields), func(f genField) bool { return f.pk }))
+
+	// ReturningColumnMap
+	fmt.Fprintf(buf, "\nfunc (m *%s) ReturningColumnMap() map[string]any {\n", t.name)
+	if len(returningFields) == 0 {
+		fmt.Fprintf(buf, "return nil\n}\n")
+	} else {
+		fmt.Fprintf(buf, "return map[string]any{\n")
+		for _, f := range returningFields {
+			fmt.Fprintf(buf, "%s: &m.%s,\n", f.constName, f.name)
+		}
+		fmt.Fprintf(buf, "}\n}\n")
+	}
+}
+
+func writeColumnMapFunc(buf *bytes.Buffer, typeName string, funcName string, fields []genField) {
+	fmt.Fprintf(buf, "\nfunc (m *%s) %s() map[string]any {\nresult := make(map[string]any, %d)\n", typeName, funcName, len(fields))
+	for _, f := range fields {
+		if f.zeroCheck == "" {
+			fmt.Fprintf(buf, "\nresult[%s] = %s\n", f.constName, f.valueExpr)
+		} else {
+			fmt.Fprintf(buf, "\nif %s {\nresult[%s] = %s\n}\n", f.zeroCheck, f.constName, f.valueExpr)
+		}
+	}
+	fmt.Fprintf(buf, "\nreturn result\n}\n")
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateUpToDate(t *testing.T) {
+	expected, err := os.ReadFile("../../tests/model/generated_mobone.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := generate("../../tests/model/generated.go", []string{"Generated", "GeneratedEdit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != string(expected) {
+		t.Errorf("tests/model/generated_mobone.go is outdated, run go generate:\n%s", result)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		typeNames []string
+		contains  []string
+		err       string
+	}{
+		{
+			name: "OmitemptyKinds",
+			src: `package p
+import "time"
+type Item struct {
+	Id     int64             ` + "`db:\"id,pk\"`" + `
+	Tags   []string          ` + "`db:\"tags,omitempty\"`" + `
+	Attrs  map[string]string ` + "`db:\"attrs,omitempty\"`" + `
+	At     time.Time         ` + "`db:\"at,omitempty\"`" + `
+	Title  string            ` + "`db:\"title,omitempty\"`" + `
+	Note   string
+	Hidden string            ` + "`db:\"-\"`" + `
+}`,
+			contains: []string{
+				`ItemColumnTags:  &m.Tags,`,
+				`if len(m.Tags) > 0 {`,
+				`if len(m.Attrs) > 0 {`,
+				`if !m.At.IsZero() {`,
+				`if m.Title != "" {`,
+				`result[ItemColumnId] = m.Id`,
+			},
+		},
+		{
+			name: "OmitemptyOtherTypes",
+			src: `package p
+import "database/sql"
+type Status string
+type Money struct {
+	Parts []int
+}
+func (m Money) IsZero() bool { return len(m.Parts) == 0 }
+type Item struct {
+	Id      int64          ` + "`db:\"id,pk\"`" + `
+	Status  Status         ` + "`db:\"status,omitempty\"`" + `
+	Amount  Money          ` + "`db:\"amount,omitempty\"`" + `
+	Comment sql.NullString ` + "`db:\"comment,omitempty\"`" + `
+}`,
+			contains: []string{
+				`"database/sql"`,
+				`if m.Status != "" {`,
+				`if !m.Amount.IsZero() {`,
+				`if m.Comment != (sql.NullString{}) {`,
+			},
+		},
+		{
+			name: "OmitemptyNotComparable",
+			src: `package p
+type Tags struct {
+	Names []string
+}
+type Item struct {
+	Tags Tags ` + "`db:\"tags,omitempty\"`" + `
+}`,
+			err: "Item.Tags: omitempty needs a comparable type or an IsZero() bool method",
+		},
+		{
+			name: "EmbeddedStruct",
+			src: `package p
+type Base struct {
+	Id int64 ` + "`db:\"id,pk\"`" + `
+}
+type Item struct {
+	Base
+	Title string ` + "`db:\"title\"`" + `
+}`,
+			typeNames: []string{"Item"},
+			contains: []string{
+				`ItemColumnId:    &m.Base.Id,`,
+				`ItemColumnId: m.Base.Id,`,
+				`result[ItemColumnTitle] = m.Title`,
+			},
+		},
+		{
+			name: "AllStructsWithTags",
+			src: `package p
+type A struct {
+	Id int ` + "`db:\"id\"`" + `
+}
+type B struct {
+	Name string
+}`,
+			contains: []string{"func (m *A) ListColumnMap()", "func (m *A) DefaultSortColumns() []string {\n\treturn nil"},
+		},
+		{
+			name:      "UnknownType",
+			src:       "package p\ntype A struct{}",
+			typeNames: []string{"B"},
+			err:       "B: struct type not found",
+		},
+		{
+			name: "UnknownOption",
+			src:  "package p\ntype A struct {\n\tId int `db:\"id,unique\"`\n}",
+			err:  `A.Id: unknown db tag option "unique"`,
+		},
+		{
+			name: "DuplicateColumn",
+			src:  "package p\ntype A struct {\n\tId int `db:\"id\"`\n\tKey int `db:\"id\"`\n}",
+			err:  `A.Key: duplicate column "id"`,
+		},
+		{
+			name:      "EmbeddedPointer",
+			src:       "package p\ntype Base struct {\n\tId int `db:\"id\"`\n}\ntype A struct {\n\t*Base\n}",
+			typeNames: []string{"A"},
+			err:       "A: embedded pointer Base is not supported",
+		},
+		{
+			name: "UnexportedField",
+			src:  "package p\ntype A struct {\n\tid int `db:\"id\"`\n}",
+			err:  "A.id: tagged field is not exported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module p\n\ngo 1.24\n"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(filepath.Join(dir, "p.go"), []byte(tt.src), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := generate(filepath.Join(dir, "p.go"), tt.typeNames)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range tt.contains {
+				if !strings.Contains(string(result), s) {
+					t.Errorf("result does not contain %q:\n%s", s, result)
+				}
+			}
+			if strings.Contains(string(result), "Note") || strings.Contains(string(result), "Hidden") {
+				t.Errorf("untagged fields are generated:\n%s", result)
+			}
+		})
+	}
+}
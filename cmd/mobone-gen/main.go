@@ -0,0 +1,63 @@
+// Command mobone-gen generates the mobone model methods (ListColumnMap, PKColumnMap,
+// CreateColumnMap, UpdateColumnMap, ReturningColumnMap, DefaultSortColumns) and column name
+// constants for structs described with `db:"name,pk,readonly,returning,omitempty"` tags.
+// Field types are resolved by loading the whole package, the generated code uses no reflection.
+//
+// Usage with go generate:
+//
+//	//go:generate go run github.com/mechta-market/mobone/v2/cmd/mobone-gen -type Item
+//
+// The result is written next to the source file as <file>_mobone.go.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("mobone-gen: ")
+
+	typeNames := flag.String("type", "", "comma-separated struct names, all structs with db tags by default")
+	input := flag.String("file", os.Getenv("GOFILE"), "source file, $GOFILE by default")
+	output := flag.String("output", "", "output file, <file>_mobone.go by default")
+	flag.Parse()
+
+	if *input == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *output == "" {
+		*output = strings.TrimSuffix(*input, filepath.Ext(*input)) + "_mobone.go"
+	}
+
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+
+	err := run(*input, *output, types)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(input, output string, typeNames []string) error {
+	result, err := generate(input, typeNames)
+	if err != nil {
+		return fmt.Errorf("%s: %w", input, err)
+	}
+
+	err = os.WriteFile(output, result, 0o644)
+	if err != nil {
+		return fmt.Errorf("fail to write result: %w", err)
+	}
+
+	return nil
+}
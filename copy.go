@@ -0,0 +1,186 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var stagingTableSeq atomic.Uint64
+
+// CopyIn loads the columns of models from src into the table with COPY.
+// Every model must provide all columns in CreateColumnMap, squirrel expressions are not supported.
+func (s *ModelStore) CopyIn(ctx context.Context, columns []string, src iter.Seq[CreateModelI]) (int64, error) {
+	con, err := s.connection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return copyModels(ctx, con, tableIdentifier(s.TableName), columns, src)
+}
+
+// CopyInMerge copies models from src into a temporary staging table and merges it into the table
+// with INSERT ... SELECT ... ON CONFLICT. The conflict target comes from onConflict (Columns or Constraint),
+// the remaining columns are updated from EXCLUDED, or nothing is updated when there are none.
+// It runs in its own transaction, or in a savepoint when ctx already holds one. Returns the number of merged rows.
+func (s *ModelStore) CopyInMerge(ctx context.Context, columns []string, onConflict OnConflict, src iter.Seq[CreateModelI]) (int64, error) {
+	if onConflict.Constraint == "" && len(onConflict.Columns) == 0 {
+		return 0, fmt.Errorf("conflict target is required")
+	}
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	beginner, ok := con.(beginnerI)
+	if !ok {
+		return 0, fmt.Errorf("connection does not support transactions")
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer rollbackDetached(ctx, tx, s.rollbackTimeout())
+
+	stagingTableName := fmt.Sprintf("mobone_staging_%d", stagingTableSeq.Add(1))
+
+	_, err = tx.Exec(ctx, `CREATE TEMP TABLE `+stagingTableName+` (LIKE `+s.TableName+` INCLUDING DEFAULTS) ON COMMIT DROP`)
+	if err != nil {
+		return 0, fmt.Errorf("fail to create staging table: %w", classifyError(err))
+	}
+
+	_, err = copyModels(ctx, tx, pgx.Identifier{stagingTableName}, columns, src)
+	if err != nil {
+		return 0, err
+	}
+
+	conflictColumns := make(map[string]bool, len(onConflict.Columns))
+	for _, colName := range onConflict.Columns {
+		conflictColumns[colName] = true
+	}
+
+	setClauses := make([]string, 0, len(columns))
+	for _, colName := range columns {
+		if !conflictColumns[colName] {
+			setClauses = append(setClauses, colName+` = EXCLUDED.`+colName)
+		}
+	}
+
+	onConflictSQL := `ON CONFLICT ` + onConflict.targetSQL()
+	var onConflictArgs []any
+	if len(setClauses) > 0 {
+		onConflictSQL += ` DO UPDATE SET ` + strings.Join(setClauses, ", ")
+		if onConflict.UpdateWhere != nil {
+			whereSQL, whereArgs, err := onConflict.UpdateWhere.ToSql()
+			if err != nil {
+				return 0, fmt.Errorf("fail to build query: %w", err)
+			}
+			onConflictSQL += ` WHERE ` + whereSQL
+			onConflictArgs = whereArgs
+		}
+	} else {
+		onConflictSQL += ` DO NOTHING`
+	}
+
+	query, args, err := s.QB.Insert(s.TableName+" as t").
+		Columns(columns...).
+		Select(squirrel.Select(columns...).From(stagingTableName)).
+		Suffix(onConflictSQL, onConflictArgs...).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("fail to build query: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("fail to exec: %w", classifyError(err))
+	}
+
+	// drop explicitly: inside a savepoint ON COMMIT DROP would wait for the outer commit
+	_, err = tx.Exec(ctx, `DROP TABLE `+stagingTableName)
+	if err != nil {
+		return 0, fmt.Errorf("fail to drop staging table: %w", classifyError(err))
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("transaction commit: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func copyModels(ctx context.Context, con ConnectionI, tableName pgx.Identifier, columns []string, src iter.Seq[CreateModelI]) (int64, error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("no columns")
+	}
+
+	next, stop := iter.Pull(src)
+	defer stop()
+
+	copySource := &modelCopySource{
+		columns: columns,
+		next:    next,
+	}
+
+	count, err := con.CopyFrom(ctx, tableName, columns, copySource)
+	if err != nil {
+		return 0, fmt.Errorf("fail to copy: %w", classifyError(err))
+	}
+
+	return count, nil
+}
+
+// modelCopySource adapts a pulled model iterator to pgx.CopyFromSource.
+type modelCopySource struct {
+	columns []string
+	next    func() (CreateModelI, bool)
+	values  []any
+	row     int
+	err     error
+}
+
+func (s *modelCopySource) Next() bool {
+	m, ok := s.next()
+	if !ok {
+		return false
+	}
+	s.row++
+
+	columnMap := m.CreateColumnMap()
+	values := make([]any, len(s.columns))
+	for i, colName := range s.columns {
+		v, ok := columnMap[colName]
+		if !ok {
+			s.err = fmt.Errorf("column %q is missing in model %d", colName, s.row)
+			return false
+		}
+		if _, ok = v.(squirrel.Sqlizer); ok {
+			s.err = fmt.Errorf("column %q of model %d is an expression, COPY needs plain values", colName, s.row)
+			return false
+		}
+		values[i] = v
+	}
+	s.values = values
+
+	return true
+}
+
+func (s *modelCopySource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *modelCopySource) Err() error {
+	return s.err
+}
+
+func tableIdentifier(tableName string) pgx.Identifier {
+	return strings.Split(tableName, ".")
+}
@@ -0,0 +1,394 @@
+package mobone
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type ListResult struct {
+	TotalCount int64
+	// NextCursor is passed as ListParams.After to get the next page, empty on the last page
+	NextCursor string
+	// PrevCursor is passed as ListParams.Before to get the previous page, empty on the first page
+	PrevCursor string
+}
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+type cursorPayload struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// ListPage lists like List, but paginates by the values of the sort columns instead of OFFSET.
+// The sort (params.Sort or DefaultSortColumns, "col" or "col asc|desc") is completed with
+// the PK columns when the item has PKColumnMap, sort columns must be non-null keys of ListColumnMap.
+// Cursors are signed with CursorSecret, a tampered or foreign cursor gives ErrInvalidCursor.
+// params.Page is ignored.
+func (s *ModelStore) ListPage(ctx context.Context, params ListParams, itemConstructor func(add bool) ListModelI) (ListResult, error) {
+	var result ListResult
+
+	if params.PageSize <= 0 {
+		return result, fmt.Errorf("page size is required for cursor pagination")
+	}
+	if params.After != "" && params.Before != "" {
+		return result, fmt.Errorf("after and before cursors are mutually exclusive")
+	}
+	if len(s.CursorSecret) == 0 {
+		return result, fmt.Errorf("cursor secret is not set")
+	}
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	listItemInstance := itemConstructor(false)
+
+	queryBuilder, colNames, err := s.buildListBase(params, listItemInstance)
+	if err != nil {
+		return result, err
+	}
+
+	sortKeys, err := keysetSortKeys(params.Sort, listItemInstance)
+	if err != nil {
+		return result, err
+	}
+
+	// total count
+	if params.WithTotalCount || params.OnlyCount {
+		result.TotalCount, err = listTotalCount(ctx, con, queryBuilder, params.Distinct, colNames)
+		if err != nil {
+			return result, err
+		}
+
+		if params.OnlyCount {
+			return result, nil
+		}
+	}
+
+	// sort columns are needed in the result to build cursors
+	for _, key := range sortKeys {
+		if !slices.Contains(colNames, key.column) {
+			colNames = append(colNames, key.column)
+		}
+	}
+
+	backward := params.Before != ""
+
+	if cursor := params.After + params.Before; cursor != "" {
+		values, err := s.decodeCursor(cursor, sortKeys)
+		if err != nil {
+			return result, err
+		}
+		queryBuilder = queryBuilder.Where(keysetPredicate(sortKeys, values, backward))
+	}
+
+	// apply columns
+	if params.Distinct {
+		queryBuilder = queryBuilder.Distinct()
+	}
+	queryBuilder = queryBuilder.Columns(colNames...)
+
+	// one extra row tells whether there is one more page
+	queryBuilder = queryBuilder.OrderBy(keysetOrderBy(sortKeys, backward)...).Limit(uint64(params.PageSize + 1))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return result, fmt.Errorf("fail to build query: %w", err)
+	}
+
+	// the previous page is selected in reverse order, so turn it back
+	if backward {
+		orderBy := make([]string, 0, len(sortKeys))
+		for _, key := range sortKeys {
+			orderBy = append(orderBy, strconv.Itoa(slices.Index(colNames, key.column)+1)+sortDirection(key.desc))
+		}
+		query = `SELECT p.*, count(*) over () FROM (` + query + `) p ORDER BY ` + strings.Join(orderBy, ",")
+	}
+
+	// execute query
+	rows, err := con.Query(ctx, query, args...)
+	if err != nil {
+		return result, fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var first, last ListModelI
+	var hasMore bool
+
+	for i := int64(0); rows.Next(); i++ {
+		var m ListModelI
+		var pageCount int64
+
+		if backward && i == 0 || !backward && i == params.PageSize {
+			m = itemConstructor(false)
+		} else {
+			m = itemConstructor(true)
+		}
+
+		fieldPointers := fieldPointersForColNames(m, colNames)
+		if backward {
+			fieldPointers = append(fieldPointers, &pageCount)
+		}
+
+		err = rows.Scan(fieldPointers...)
+		if err != nil {
+			return result, fmt.Errorf("fail to scan: %w", err)
+		}
+
+		if backward && i == 0 && pageCount <= params.PageSize {
+			// no extra row, the first one belongs to the page
+			m = copyListColumns(itemConstructor(true), m, colNames)
+		} else if backward && i == 0 || !backward && i == params.PageSize {
+			hasMore = true
+			continue
+		}
+
+		if first == nil {
+			first = m
+		}
+		last = m
+	}
+	if err = rows.Err(); err != nil {
+		return result, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	if last == nil {
+		return result, nil
+	}
+
+	if backward || hasMore {
+		result.NextCursor, err = s.encodeCursor(last, sortKeys)
+		if err != nil {
+			return result, err
+		}
+	}
+	if backward && hasMore || !backward && params.After != "" {
+		result.PrevCursor, err = s.encodeCursor(first, sortKeys)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// keysetSortKeys parses the sort and completes it with the PK columns as a tie-breaker.
+func keysetSortKeys(sort []string, m ListModelI) ([]sortKey, error) {
+	if sort == nil {
+		sort = m.DefaultSortColumns()
+	}
+
+	colMap := m.ListColumnMap()
+
+	result := make([]sortKey, 0, len(sort)+1)
+	for _, item := range sort {
+		fields := strings.Fields(item)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("unsupported sort %q for cursor pagination", item)
+		}
+
+		key := sortKey{column: fields[0]}
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				key.desc = true
+			default:
+				return nil, fmt.Errorf("unsupported sort %q for cursor pagination", item)
+			}
+		}
+
+		if _, ok := colMap[key.column]; !ok {
+			return nil, fmt.Errorf("sort column %q is not in the list columns", key.column)
+		}
+
+		result = append(result, key)
+	}
+
+	if pkModel, ok := m.(interface{ PKColumnMap() map[string]any }); ok {
+		pkColNames := make([]string, 0, 1)
+		for colName := range pkModel.PKColumnMap() {
+			pkColNames = append(pkColNames, colName)
+		}
+		slices.Sort(pkColNames)
+
+		for _, colName := range pkColNames {
+			if slices.ContainsFunc(result, func(key sortKey) bool { return key.column == colName }) {
+				continue
+			}
+			if _, ok := colMap[colName]; !ok {
+				return nil, fmt.Errorf("pk column %q is not in the list columns", colName)
+			}
+			result = append(result, sortKey{column: colName})
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("cursor pagination requires a sort")
+	}
+
+	return result, nil
+}
+
+// keysetPredicate selects the rows after (or before) values in the sort order:
+// (a > $1) OR (a = $1 AND b > $2) ...
+func keysetPredicate(sortKeys []sortKey, values []string, backward bool) squirrel.Sqlizer {
+	result := make(squirrel.Or, 0, len(sortKeys))
+	for i, key := range sortKeys {
+		op := ` > ?`
+		if key.desc != backward {
+			op = ` < ?`
+		}
+
+		pred := make(squirrel.And, 0, i+1)
+		for j := range i {
+			pred = append(pred, squirrel.Expr(sortKeys[j].column+` = ?`, values[j]))
+		}
+		pred = append(pred, squirrel.Expr(key.column+op, values[i]))
+
+		result = append(result, pred)
+	}
+	return result
+}
+
+func keysetOrderBy(sortKeys []sortKey, backward bool) []string {
+	result := make([]string, 0, len(sortKeys))
+	for _, key := range sortKeys {
+		result = append(result, key.column+sortDirection(key.desc != backward))
+	}
+	return result
+}
+
+func sortDirection(desc bool) string {
+	if desc {
+		return ` desc`
+	}
+	return ` asc`
+}
+
+// copyListColumns copies the colNames fields of src into dst and returns dst.
+func copyListColumns(dst, src ListModelI, colNames []string) ListModelI {
+	srcPointers := fieldPointersForColNames(src, colNames)
+	for i, dstPointer := range fieldPointersForColNames(dst, colNames) {
+		reflect.ValueOf(dstPointer).Elem().Set(reflect.ValueOf(srcPointers[i]).Elem())
+	}
+	return dst
+}
+
+func sortSignature(sortKeys []sortKey) string {
+	return strings.Join(keysetOrderBy(sortKeys, false), ",")
+}
+
+func (s *ModelStore) encodeCursor(m ListModelI, sortKeys []sortKey) (string, error) {
+	colMap := m.ListColumnMap()
+
+	payload := cursorPayload{Sort: sortSignature(sortKeys), Values: make([]string, 0, len(sortKeys))}
+	for _, key := range sortKeys {
+		value, err := cursorValue(colMap[key.column])
+		if err != nil {
+			return "", fmt.Errorf("sort column %q: %w", key.column, err)
+		}
+		payload.Values = append(payload.Values, value)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("fail to marshal cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(s.cursorMAC(data)), nil
+}
+
+func (s *ModelStore) decodeCursor(cursor string, sortKeys []sortKey) ([]string, error) {
+	encodedData, encodedMAC, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encodedData)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.cursorMAC(data)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err = json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// a cursor of another sort can not be applied
+	if payload.Sort != sortSignature(sortKeys) || len(payload.Values) != len(sortKeys) {
+		return nil, ErrInvalidCursor
+	}
+
+	return payload.Values, nil
+}
+
+func (s *ModelStore) cursorMAC(data []byte) []byte {
+	h := hmac.New(sha256.New, s.CursorSecret)
+	h.Write([]byte(s.TableName))
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// cursorValue formats the value of fieldPointer as postgres text input,
+// so it can be sent as a query argument of any column type.
+func cursorValue(fieldPointer any) (string, error) {
+	v := reflect.ValueOf(fieldPointer)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", fmt.Errorf("null values are not supported by cursor pagination")
+		}
+		v = v.Elem()
+	}
+
+	value := v.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		if dv == nil {
+			return "", fmt.Errorf("null values are not supported by cursor pagination")
+		}
+		value = dv
+	}
+
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	case []byte:
+		return `\x` + hex.EncodeToString(value), nil
+	case [16]byte:
+		h := hex.EncodeToString(value[:])
+		return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+	case fmt.Stringer:
+		return value.String(), nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
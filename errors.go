@@ -0,0 +1,94 @@
+package mobone
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	pgCodeNotNullViolation     = "23502"
+	pgCodeForeignKeyViolation  = "23503"
+	pgCodeUniqueViolation      = "23505"
+	pgCodeCheckViolation       = "23514"
+	pgCodeSerializationFailure = "40001"
+	pgCodeDeadlockDetected     = "40P01"
+)
+
+var (
+	ErrNotFound            = errors.New("mobone: not found")
+	ErrUniqueViolation     = errors.New("mobone: unique violation")
+	ErrForeignKeyViolation = errors.New("mobone: foreign key violation")
+	ErrCheckViolation      = errors.New("mobone: check violation")
+	ErrNotNullViolation    = errors.New("mobone: not null violation")
+	ErrSerialization       = errors.New("mobone: serialization failure")
+	ErrDeadlock            = errors.New("mobone: deadlock detected")
+
+	ErrTransactionManagerMismatch = errors.New("mobone: transaction manager and store use different pools")
+	ErrEmptyConditions            = errors.New("mobone: empty conditions")
+	ErrInvalidCursor              = errors.New("mobone: invalid cursor")
+	ErrBatchStoreMismatch         = errors.New("mobone: batch store uses a different pool or transaction manager")
+)
+
+// DBError is a classified database error. It matches its Kind sentinel with errors.Is
+// and still unwraps to the original *pgconn.PgError for errors.As.
+type DBError struct {
+	Kind       error
+	Code       string
+	Constraint string
+	Table      string
+	Column     string
+	Err        error
+}
+
+func (e *DBError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DBError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// classifyError wraps known database errors into *DBError, other errors are returned as is.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &DBError{Kind: ErrNotFound, Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var kind error
+	switch pgErr.Code {
+	case pgCodeUniqueViolation:
+		kind = ErrUniqueViolation
+	case pgCodeForeignKeyViolation:
+		kind = ErrForeignKeyViolation
+	case pgCodeCheckViolation:
+		kind = ErrCheckViolation
+	case pgCodeNotNullViolation:
+		kind = ErrNotNullViolation
+	case pgCodeSerializationFailure:
+		kind = ErrSerialization
+	case pgCodeDeadlockDetected:
+		kind = ErrDeadlock
+	default:
+		return err
+	}
+
+	return &DBError{
+		Kind:       kind,
+		Code:       pgErr.Code,
+		Constraint: pgErr.ConstraintName,
+		Table:      pgErr.TableName,
+		Column:     pgErr.ColumnName,
+		Err:        err,
+	}
+}
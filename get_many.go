@@ -0,0 +1,183 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type GetManyParams struct {
+	// Keys are PKColumnMap-like maps, all with the same columns
+	Keys []map[string]any
+	// KeepOrder returns items in the order of Keys instead of the database order
+	KeepOrder bool
+}
+
+// GetMany fetches the rows of params.Keys in one query (chunked for composite keys), joining the table
+// with the keys: unnest($1::type[]) WITH ORDINALITY for single column keys and a VALUES list for composite ones.
+// Keys are matched with rows by PostgreSQL, so equal keys after the column type cast (an upper case uuid,
+// a citext value, numeric 1.50 and 1.5) find the same row, which is returned once.
+// It returns the found items and the keys that were not found, in the order of params.Keys.
+func (s *ModelStore) GetMany(ctx context.Context, params GetManyParams, itemConstructor func() GetModelI) ([]GetModelI, []map[string]any, error) {
+	if len(params.Keys) == 0 {
+		return nil, nil, nil
+	}
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pkColNames := unionColumnNames(params.Keys[:1])
+	if len(pkColNames) == 0 {
+		return nil, nil, fmt.Errorf("no key columns")
+	}
+
+	for i, key := range params.Keys {
+		if len(key) != len(pkColNames) {
+			return nil, nil, fmt.Errorf("key %d has different columns", i)
+		}
+		for _, colName := range pkColNames {
+			if _, ok := key[colName]; !ok {
+				return nil, nil, fmt.Errorf("key %d has different columns", i)
+			}
+		}
+	}
+
+	probe := itemConstructor()
+	colNames := allowedColumnNames(probe.ListColumnMap(), nil)
+	if len(colNames) == 0 {
+		return nil, nil, fmt.Errorf("no columns")
+	}
+
+	colTypes, err := s.columnTypes(ctx, con)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyColNames := make([]string, len(pkColNames))
+	joinConditions := make([]string, len(pkColNames))
+	placeholders := make([]string, len(pkColNames))
+	for i, colName := range pkColNames {
+		colType, ok := colTypes[colName]
+		if !ok {
+			return nil, nil, fmt.Errorf("column %q not found in table %s", colName, s.TableName)
+		}
+
+		keyColNames[i] = fmt.Sprintf("mobone_key_%d", i)
+		joinConditions[i] = s.TableName + `.` + colName + ` = mobone_keys.` + keyColNames[i]
+		placeholders[i] = `?::` + colType
+	}
+
+	// items by the index of their first key, keys of a row share it
+	items := make([]GetModelI, len(params.Keys))
+	found := make([]bool, len(params.Keys))
+	var foundOrder []int
+
+	keysPerChunk := len(params.Keys)
+	if len(pkColNames) > 1 {
+		keysPerChunk = maxQueryParams / len(pkColNames)
+	}
+	for chunkStart := 0; chunkStart < len(params.Keys); chunkStart += keysPerChunk {
+		chunk := params.Keys[chunkStart:min(chunkStart+keysPerChunk, len(params.Keys))]
+
+		// ord is the 1-based key index in the chunk, equal keys are grouped into one ords array
+		var keysSQL string
+		var args []any
+		if len(pkColNames) == 1 {
+			values := make([]any, len(chunk))
+			for i, key := range chunk {
+				values[i] = key[pkColNames[0]]
+			}
+			keysSQL = `unnest(` + placeholders[0] + `[]) WITH ORDINALITY k(` + keyColNames[0] + `, ord)`
+			args = []any{values}
+		} else {
+			rowsSQL := make([]string, len(chunk))
+			args = make([]any, 0, len(chunk)*len(pkColNames))
+			for i, key := range chunk {
+				rowsSQL[i] = `(` + strings.Join(placeholders, ",") + `,` + strconv.Itoa(i+1) + `)`
+				for _, colName := range pkColNames {
+					args = append(args, key[colName])
+				}
+			}
+			keysSQL = `(VALUES ` + strings.Join(rowsSQL, ",") + `) k(` + strings.Join(keyColNames, ",") + `, ord)`
+		}
+
+		groupBy := strings.Join(keyColNames, ",")
+		queryBuilder := s.QB.Select(append(slices.Clone(colNames), "mobone_keys.mobone_ords")...).
+			From(s.TableName).
+			Join(`(SELECT `+groupBy+`, array_agg(ord ORDER BY ord) mobone_ords FROM `+keysSQL+` GROUP BY `+groupBy+`) mobone_keys ON `+strings.Join(joinConditions, " AND "), args...)
+
+		if qbInterceptor, ok := probe.(WithGetInterceptorI); ok && qbInterceptor != nil {
+			queryBuilder = qbInterceptor.GetInterceptor(queryBuilder)
+		}
+
+		query, args, err := queryBuilder.ToSql()
+		if err != nil {
+			return nil, nil, fmt.Errorf("fail to build query: %w", err)
+		}
+
+		rows, err := con.Query(ctx, query, args...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fail to query: %w", classifyError(err))
+		}
+
+		var ords []int64
+		for rows.Next() {
+			m := itemConstructor()
+
+			colMap := m.ListColumnMap()
+			fieldPointers := make([]any, 0, len(colNames)+1)
+			for _, colName := range colNames {
+				fieldPointers = append(fieldPointers, colMap[colName])
+			}
+			fieldPointers = append(fieldPointers, &ords)
+
+			err = rows.Scan(fieldPointers...)
+			if err != nil {
+				rows.Close()
+				return nil, nil, fmt.Errorf("fail to scan: %w", err)
+			}
+
+			// a key matching several rows keeps the first one
+			keyIndex := chunkStart + int(ords[0]) - 1
+			if found[keyIndex] {
+				continue
+			}
+			for _, ord := range ords {
+				found[chunkStart+int(ord)-1] = true
+			}
+
+			items[keyIndex] = m
+			foundOrder = append(foundOrder, keyIndex)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, nil, fmt.Errorf("rows.Err: %w", classifyError(err))
+		}
+	}
+
+	result := make([]GetModelI, 0, len(foundOrder))
+	if params.KeepOrder {
+		for _, m := range items {
+			if m != nil {
+				result = append(result, m)
+			}
+		}
+	} else {
+		for _, keyIndex := range foundOrder {
+			result = append(result, items[keyIndex])
+		}
+	}
+
+	var missingKeys []map[string]any
+	for i, key := range params.Keys {
+		if !found[i] {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+
+	return result, missingKeys, nil
+}
@@ -16,4 +16,12 @@ type ConnectionI interface {
 	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// beginnerI is implemented by both the pool and pgx.Tx (as a savepoint),
+// it is used by operations that need a transaction of their own.
+type beginnerI interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
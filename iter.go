@@ -0,0 +1,69 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// ListIter streams the rows of a List query one at a time, without accumulating them.
+// Pagination and sort are the same as in List, WithTotalCount and OnlyCount are ignored.
+// The rows are released when the loop ends, including an early break.
+// Inside TxFn the transaction of ctx is used, it must not be used for other queries while iterating.
+func ListIter[T any, PT interface {
+	*T
+	ListModelI
+}](ctx context.Context, s *ModelStore, params ListParams) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		if params.After != "" || params.Before != "" {
+			yield(zero, fmt.Errorf("cursor pagination is not supported by ListIter"))
+			return
+		}
+
+		con, err := s.connection(ctx)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		listItemInstance := PT(new(T))
+
+		queryBuilder, colNames, err := s.buildListBase(params, listItemInstance)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		query, args, err := buildListSelect(queryBuilder, params, listItemInstance, colNames)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		rows, err := con.Query(ctx, query, args...)
+		if err != nil {
+			yield(zero, fmt.Errorf("fail to query: %w", classifyError(err)))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var item T
+
+			err = rows.Scan(fieldPointersForColNames(PT(&item), colNames)...)
+			if err != nil {
+				yield(zero, fmt.Errorf("fail to scan: %w", err))
+				return
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+		if err = rows.Err(); err != nil {
+			yield(zero, fmt.Errorf("rows.Err: %w", err))
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
@@ -44,6 +45,16 @@ type WithListInterceptorI interface {
 	ListInterceptor(qb squirrel.SelectBuilder, params ListParams) squirrel.SelectBuilder
 }
 
+// WithReturningI opts Update, Delete, UpdateOrCreate and CreateIfNotExist into RETURNING,
+// Create keeps using CreateModelI.ReturningColumnMap.
+type WithReturningI interface {
+	WriteReturningColumnMap() map[string]any
+}
+
+type WithOnConflictI interface {
+	OnConflict() OnConflict
+}
+
 type WithGetInterceptorI interface {
 	GetInterceptor(qb squirrel.SelectBuilder) squirrel.SelectBuilder
 }
@@ -63,6 +74,41 @@ type ListParams struct {
 	OnlyCount            bool
 	Sort                 []string
 	CustomConditions     map[string]string
+
+	// After and Before switch List to keyset pagination, see ListPage
+	After  string
+	Before string
+}
+
+// OnConflict customizes the ON CONFLICT clause of UpdateOrCreate and CreateIfNotExist.
+type OnConflict struct {
+	// Columns of the conflict target, PKColumnMap keys are used when empty
+	Columns []string
+	// Constraint is used as ON CONSTRAINT target instead of Columns
+	Constraint string
+	// IndexPredicate is the WHERE of a partial unique index, for example "deleted_at is null"
+	IndexPredicate string
+	// UpdateWhere limits DO UPDATE, for example squirrel.Expr("excluded.updated_at > t.updated_at")
+	UpdateWhere squirrel.Sqlizer
+}
+
+type UpsertResult int8
+
+const (
+	UpsertSkipped UpsertResult = iota
+	UpsertInserted
+	UpsertUpdated
+)
+
+func (r UpsertResult) String() string {
+	switch r {
+	case UpsertInserted:
+		return "inserted"
+	case UpsertUpdated:
+		return "updated"
+	default:
+		return "skipped"
+	}
 }
 
 type ModelStore struct {
@@ -70,57 +116,108 @@ type ModelStore struct {
 	TransactionManager connectionGetterI
 	QB                 squirrel.StatementBuilderType
 	TableName          string
+
+	// RequireRowsAffected makes Update and Delete return ErrNotFound when no row matched PKColumnMap
+	RequireRowsAffected bool
+
+	// CursorSecret signs the cursors of ListPage
+	CursorSecret []byte
 }
 
+// rollbackTimeout is the RollbackTimeout of the store's TransactionManager, zero for the default one.
+func (s *ModelStore) rollbackTimeout() time.Duration {
+	if tm, ok := s.TransactionManager.(*TransactionManager); ok {
+		return tm.RollbackTimeout
+	}
+	return 0
+}
+
+// GetConnection returns the transaction of ctx or the pool. It panics when
+// the store is misconfigured with a TransactionManager of another pool.
 func (s *ModelStore) GetConnection(ctx context.Context) ConnectionI {
+	con, err := s.connection(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return con
+}
+
+func (s *ModelStore) connection(ctx context.Context) (ConnectionI, error) {
 	if s.TransactionManager != nil {
-		return s.TransactionManager.GetConnection(ctx)
+		if tm, ok := s.TransactionManager.(*TransactionManager); ok && s.Con != nil && tm.con != s.Con {
+			return nil, ErrTransactionManagerMismatch
+		}
+
+		return s.TransactionManager.GetConnection(ctx), nil
 	}
 
-	return s.Con
+	return s.Con, nil
 }
 
 func (s *ModelStore) Create(ctx context.Context, m CreateModelI) error {
-	con := s.GetConnection(ctx)
+	con, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
 
-	queryBuilder := s.QB.Insert(s.TableName).
-		SetMap(m.CreateColumnMap())
+	query, args, returningFieldPointers, err := s.buildCreate(m)
+	if err != nil {
+		return fmt.Errorf("fail to build query: %w", err)
+	}
 
-	returningColumnMap := m.ReturningColumnMap()
-	returningColumnNames := make([]string, 0, len(returningColumnMap))
-	returningFieldPointers := make([]any, 0, len(returningColumnMap))
-	for k, v := range returningColumnMap {
-		returningColumnNames = append(returningColumnNames, k)
-		returningFieldPointers = append(returningFieldPointers, v)
+	_, err = execReturning(ctx, con, query, args, returningFieldPointers)
+	if err != nil {
+		return err
 	}
 
+	return nil
+}
+
+func (s *ModelStore) buildCreate(m CreateModelI) (string, []any, []any, error) {
+	queryBuilder := s.QB.Insert(s.TableName).
+		SetMap(m.CreateColumnMap())
+
+	returningColumnNames, returningFieldPointers := columnNamesAndPointers(m.ReturningColumnMap())
 	if len(returningColumnNames) > 0 {
 		queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(returningColumnNames, ","))
 	}
 
 	query, args, err := queryBuilder.ToSql()
+
+	return query, args, returningFieldPointers, err
+}
+
+func (s *ModelStore) Update(ctx context.Context, m UpdateModelI) error {
+	rowsAffected, err := s.UpdateRows(ctx, m)
 	if err != nil {
-		return fmt.Errorf("fail to build query: %w", err)
+		return err
 	}
 
-	if len(returningColumnNames) > 0 {
-		err = con.QueryRow(ctx, query, args...).Scan(returningFieldPointers...)
-		if err != nil {
-			return fmt.Errorf("fail to query: %w", err)
-		}
-	} else {
-		_, err = con.Exec(ctx, query, args...)
-		if err != nil {
-			return fmt.Errorf("fail to exec: %w", err)
-		}
+	if s.RequireRowsAffected && rowsAffected == 0 {
+		return ErrNotFound
 	}
 
 	return nil
 }
 
-func (s *ModelStore) Update(ctx context.Context, m UpdateModelI) error {
-	con := s.GetConnection(ctx)
+func (s *ModelStore) UpdateRows(ctx context.Context, m UpdateModelI) (int64, error) {
+	con, err := s.connection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query, args, returningFieldPointers, err := s.buildUpdate(m)
+	if err != nil {
+		return 0, fmt.Errorf("fail to build query: %w", err)
+	}
 
+	// fmt.Println(query, args)
+
+	return execReturning(ctx, con, query, args, returningFieldPointers)
+}
+
+func (s *ModelStore) buildUpdate(m UpdateModelI) (string, []any, []any, error) {
 	queryBuilder := s.QB.Update(s.TableName).
 		SetMap(m.UpdateColumnMap())
 
@@ -128,30 +225,29 @@ func (s *ModelStore) Update(ctx context.Context, m UpdateModelI) error {
 		queryBuilder = queryBuilder.Where(k+` = ?`, v)
 	}
 
-	query, args, err := queryBuilder.ToSql()
-	if err != nil {
-		return fmt.Errorf("fail to build query: %w", err)
+	returningColumnNames, returningFieldPointers := returningColumns(m)
+	if len(returningColumnNames) > 0 {
+		queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(returningColumnNames, ","))
 	}
 
-	// fmt.Println(query, args)
-
-	_, err = con.Exec(ctx, query, args...)
-	if err != nil {
-		return fmt.Errorf("fail to exec: %w", err)
-	}
+	query, args, err := queryBuilder.ToSql()
 
-	return nil
+	return query, args, returningFieldPointers, err
 }
 
 func (s *ModelStore) UpdateOrCreate(ctx context.Context, m UpdateCreateModelI) error {
-	con := s.GetConnection(ctx)
+	_, err := s.UpdateOrCreateWithResult(ctx, m)
+	return err
+}
 
-	pkColumnMap := m.PKColumnMap()
-	pkColumnNames := make([]string, 0, len(pkColumnMap))
-	for k := range pkColumnMap {
-		pkColumnNames = append(pkColumnNames, k)
+func (s *ModelStore) UpdateOrCreateWithResult(ctx context.Context, m UpdateCreateModelI) (UpsertResult, error) {
+	con, err := s.connection(ctx)
+	if err != nil {
+		return UpsertSkipped, err
 	}
 
+	onConflict := onConflictForModel(m)
+
 	updateColumnMap := m.UpdateColumnMap()
 	updateColumnNames := make([]string, 0, len(updateColumnMap))
 	updateColumnValues := make([]any, 0, len(updateColumnMap))
@@ -160,143 +256,223 @@ func (s *ModelStore) UpdateOrCreate(ctx context.Context, m UpdateCreateModelI) e
 		updateColumnValues = append(updateColumnValues, v)
 	}
 
+	onConflictSQL := `ON CONFLICT ` + onConflict.targetSQL() + ` DO UPDATE SET ` + strings.Join(updateColumnNames, " = ?, ") + ` = ?`
+	if onConflict.UpdateWhere != nil {
+		whereSQL, whereArgs, err := onConflict.UpdateWhere.ToSql()
+		if err != nil {
+			return UpsertSkipped, fmt.Errorf("fail to build query: %w", err)
+		}
+		onConflictSQL += ` WHERE ` + whereSQL
+		updateColumnValues = append(updateColumnValues, whereArgs...)
+	}
+
 	queryBuilder := s.QB.Insert(s.TableName+" as t").
 		SetMap(m.CreateColumnMap()).
-		Suffix(`ON CONFLICT (`+strings.Join(pkColumnNames, ",")+`) DO UPDATE SET `+strings.Join(updateColumnNames, " = ?, ")+` = ?`, updateColumnValues...)
+		Suffix(onConflictSQL, updateColumnValues...)
+
+	// xmax of a freshly inserted row version is 0, an updated one carries the locking transaction id
+	var inserted bool
+	returningColumnNames, returningFieldPointers := returningColumns(m)
+	returningColumnNames = append([]string{`(xmax = 0)`}, returningColumnNames...)
+	returningFieldPointers = append([]any{&inserted}, returningFieldPointers...)
+	queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(returningColumnNames, ","))
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		return fmt.Errorf("fail to build query: %w", err)
+		return UpsertSkipped, fmt.Errorf("fail to build query: %w", err)
 	}
 
-	_, err = con.Exec(ctx, query, args...)
+	rowsAffected, err := execReturning(ctx, con, query, args, returningFieldPointers)
 	if err != nil {
-		return fmt.Errorf("fail to exec: %w", err)
+		return UpsertSkipped, err
 	}
 
-	return nil
+	switch {
+	case rowsAffected == 0:
+		return UpsertSkipped, nil
+	case inserted:
+		return UpsertInserted, nil
+	default:
+		return UpsertUpdated, nil
+	}
 }
 
 func (s *ModelStore) CreateIfNotExist(ctx context.Context, m UpdateCreateModelI) error {
-	con := s.GetConnection(ctx)
+	_, err := s.CreateIfNotExistWithResult(ctx, m)
+	return err
+}
 
-	pkColumnMap := m.PKColumnMap()
-	pkColumnNames := make([]string, 0, len(pkColumnMap))
-	for k := range pkColumnMap {
-		pkColumnNames = append(pkColumnNames, k)
+func (s *ModelStore) CreateIfNotExistWithResult(ctx context.Context, m UpdateCreateModelI) (UpsertResult, error) {
+	con, err := s.connection(ctx)
+	if err != nil {
+		return UpsertSkipped, err
 	}
 
+	// PK values are inserted only for the default PK conflict target,
+	// an explicit one may leave the PK to the database, for example a serial id
 	insertColumnMap := m.CreateColumnMap()
-	for k, v := range pkColumnMap {
-		insertColumnMap[k] = v
+	if onConflictModel, ok := m.(WithOnConflictI); !ok || onConflictModel.OnConflict().isDefaultTarget() {
+		for k, v := range m.PKColumnMap() {
+			insertColumnMap[k] = v
+		}
 	}
 
 	queryBuilder := s.QB.Insert(s.TableName).
 		SetMap(insertColumnMap).
-		Suffix(`ON CONFLICT (` + strings.Join(pkColumnNames, ",") + `) DO NOTHING`)
+		Suffix(`ON CONFLICT ` + onConflictForModel(m).targetSQL() + ` DO NOTHING`)
+
+	// nothing is returned when the row already exists, the model is left untouched then
+	returningColumnNames, returningFieldPointers := returningColumns(m)
+	if len(returningColumnNames) > 0 {
+		queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(returningColumnNames, ","))
+	}
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		return fmt.Errorf("fail to build query: %w", err)
+		return UpsertSkipped, fmt.Errorf("fail to build query: %w", err)
 	}
 
-	_, err = con.Exec(ctx, query, args...)
+	rowsAffected, err := execReturning(ctx, con, query, args, returningFieldPointers)
 	if err != nil {
-		return fmt.Errorf("fail to exec: %w", err)
+		return UpsertSkipped, err
 	}
 
-	return nil
+	if rowsAffected == 0 {
+		return UpsertSkipped, nil
+	}
+
+	return UpsertInserted, nil
 }
 
 func (s *ModelStore) Delete(ctx context.Context, m DeleteModelI) error {
-	con := s.GetConnection(ctx)
-
-	queryBuilder := s.QB.Delete(s.TableName)
+	rowsAffected, err := s.DeleteRows(ctx, m)
+	if err != nil {
+		return err
+	}
 
-	for k, v := range m.PKColumnMap() {
-		queryBuilder = queryBuilder.Where(k+` = ?`, v)
+	if s.RequireRowsAffected && rowsAffected == 0 {
+		return ErrNotFound
 	}
 
-	query, args, err := queryBuilder.ToSql()
+	return nil
+}
+
+func (s *ModelStore) DeleteRows(ctx context.Context, m DeleteModelI) (int64, error) {
+	con, err := s.connection(ctx)
 	if err != nil {
-		return fmt.Errorf("fail to build query: %w", err)
+		return 0, err
 	}
 
-	_, err = con.Exec(ctx, query, args...)
+	query, args, returningFieldPointers, err := s.buildDelete(m)
 	if err != nil {
-		return fmt.Errorf("fail to exec: %w", err)
+		return 0, fmt.Errorf("fail to build query: %w", err)
 	}
 
-	return nil
+	return execReturning(ctx, con, query, args, returningFieldPointers)
 }
 
-func (s *ModelStore) List(ctx context.Context, params ListParams, itemConstructor func(add bool) ListModelI) (int64, error) {
-	con := s.GetConnection(ctx)
-
-	queryBuilder := s.QB.Select().From(s.TableName)
+func (s *ModelStore) buildDelete(m DeleteModelI) (string, []any, []any, error) {
+	queryBuilder := s.QB.Delete(s.TableName)
 
-	// conditions
-	if params.Conditions != nil {
-		queryBuilder = queryBuilder.Where(params.Conditions)
+	for k, v := range m.PKColumnMap() {
+		queryBuilder = queryBuilder.Where(k+` = ?`, v)
 	}
-	if params.ConditionExpressions != nil {
-		for expression, args := range params.ConditionExpressions {
-			queryBuilder = queryBuilder.Where(expression, args...)
-		}
+
+	returningColumnNames, returningFieldPointers := returningColumns(m)
+	if len(returningColumnNames) > 0 {
+		queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(returningColumnNames, ","))
 	}
 
-	var totalCount int64
+	query, args, err := queryBuilder.ToSql()
 
-	listItemInstance := itemConstructor(false)
+	return query, args, returningFieldPointers, err
+}
 
-	// construct column names
-	allowedColMap := listItemInstance.ListColumnMap()
-	colNames := make([]string, 0, len(params.Columns))
-	if len(params.Columns) > 0 {
-		var ok bool
-		for _, colName := range params.Columns {
-			if _, ok = allowedColMap[colName]; ok {
-				colNames = append(colNames, colName)
-			}
-		}
-	} else {
-		for colName := range allowedColMap {
-			colNames = append(colNames, colName)
-		}
+func (s *ModelStore) List(ctx context.Context, params ListParams, itemConstructor func(add bool) ListModelI) (int64, error) {
+	if params.After != "" || params.Before != "" {
+		result, err := s.ListPage(ctx, params, itemConstructor)
+		return result.TotalCount, err
 	}
-	if len(colNames) == 0 {
-		return 0, fmt.Errorf("no columns")
+
+	con, err := s.connection(ctx)
+	if err != nil {
+		return 0, err
 	}
 
-	if qbInterceptor, ok := listItemInstance.(WithListInterceptorI); ok && qbInterceptor != nil {
-		queryBuilder = qbInterceptor.ListInterceptor(queryBuilder, params)
+	listItemInstance := itemConstructor(false)
+
+	queryBuilder, colNames, err := s.buildListBase(params, listItemInstance)
+	if err != nil {
+		return 0, err
 	}
 
+	var totalCount int64
+
 	// total count
 	if params.WithTotalCount || params.OnlyCount {
-		if params.Distinct {
-			queryBuilder = queryBuilder.Column(`count(distinct (` + strings.Join(colNames, ",") + `))`)
-		} else {
-			queryBuilder = queryBuilder.Column(`count(*)`)
+		totalCount, err = listTotalCount(ctx, con, queryBuilder, params.Distinct, colNames)
+		if err != nil {
+			return 0, err
 		}
 
-		query, args, err := queryBuilder.ToSql()
-		if err != nil {
-			return 0, fmt.Errorf("fail to build query: %w", err)
+		if params.OnlyCount {
+			return totalCount, nil
 		}
+	}
 
-		err = con.QueryRow(ctx, query, args...).Scan(&totalCount)
+	query, args, err := buildListSelect(queryBuilder, params, listItemInstance, colNames)
+	if err != nil {
+		return 0, err
+	}
+
+	// slog.Info("List query", "query", query, "args", args)
+
+	// execute query
+	rows, err := con.Query(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := itemConstructor(true)
+
+		err = rows.Scan(fieldPointersForColNames(m, colNames)...)
 		if err != nil {
-			return 0, fmt.Errorf("fail to query: %w", err)
+			return 0, fmt.Errorf("fail to scan: %w", err)
 		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows.Err: %w", err)
+	}
 
-		if params.OnlyCount {
-			return totalCount, nil
-		}
+	return totalCount, nil
+}
+
+// buildListBase returns the List query without columns, sort and pagination, and the column names to select.
+func (s *ModelStore) buildListBase(params ListParams, listItemInstance ListModelI) (squirrel.SelectBuilder, []string, error) {
+	queryBuilder := s.QB.Select().From(s.TableName)
+
+	// conditions
+	for _, pred := range conditionPredicates(params.Conditions, params.ConditionExpressions) {
+		queryBuilder = queryBuilder.Where(pred)
+	}
 
-		queryBuilder = queryBuilder.RemoveColumns()
+	// construct column names
+	colNames := allowedColumnNames(listItemInstance.ListColumnMap(), params.Columns)
+	if len(colNames) == 0 {
+		return queryBuilder, nil, fmt.Errorf("no columns")
 	}
 
+	if qbInterceptor, ok := listItemInstance.(WithListInterceptorI); ok && qbInterceptor != nil {
+		queryBuilder = qbInterceptor.ListInterceptor(queryBuilder, params)
+	}
+
+	return queryBuilder, colNames, nil
+}
+
+// buildListSelect completes the List query with columns, pagination and sort.
+func buildListSelect(queryBuilder squirrel.SelectBuilder, params ListParams, listItemInstance ListModelI, colNames []string) (string, []any, error) {
 	// apply columns
 	if params.Distinct {
 		queryBuilder = queryBuilder.Distinct()
@@ -321,36 +497,57 @@ func (s *ModelStore) List(ctx context.Context, params ListParams, itemConstructo
 	// build query
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		return 0, fmt.Errorf("fail to build query: %w", err)
+		return "", nil, fmt.Errorf("fail to build query: %w", err)
 	}
 
-	// slog.Info("List query", "query", query, "args", args)
+	return query, args, nil
+}
 
-	// execute query
-	rows, err := con.Query(ctx, query, args...)
+func listTotalCount(ctx context.Context, con ConnectionI, queryBuilder squirrel.SelectBuilder, distinct bool, colNames []string) (int64, error) {
+	if distinct {
+		queryBuilder = queryBuilder.Column(`count(distinct (` + strings.Join(colNames, ",") + `))`)
+	} else {
+		queryBuilder = queryBuilder.Column(`count(*)`)
+	}
+
+	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		return 0, fmt.Errorf("fail to query: %w", err)
+		return 0, fmt.Errorf("fail to build query: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		m := itemConstructor(true)
+	var totalCount int64
 
-		err = rows.Scan(fieldPointersForColNames(m, colNames)...)
-		if err != nil {
-			return 0, fmt.Errorf("fail to scan: %w", err)
-		}
-	}
-	if err = rows.Err(); err != nil {
-		return 0, fmt.Errorf("rows.Err: %w", err)
+	err = con.QueryRow(ctx, query, args...).Scan(&totalCount)
+	if err != nil {
+		return 0, fmt.Errorf("fail to query: %w", classifyError(err))
 	}
 
 	return totalCount, nil
 }
 
 func (s *ModelStore) Get(ctx context.Context, m GetModelI) (bool, error) {
-	con := s.GetConnection(ctx)
+	con, err := s.connection(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	query, args, colFieldPointers, err := s.buildGet(m)
+	if err != nil {
+		return false, err
+	}
+
+	err = con.QueryRow(ctx, query, args...).Scan(colFieldPointers...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+
+	return true, nil
+}
 
+func (s *ModelStore) buildGet(m GetModelI) (string, []any, []any, error) {
 	colMap := m.ListColumnMap()
 	colNames := make([]string, 0, len(colMap))
 	colFieldPointers := make([]any, 0, len(colMap))
@@ -360,7 +557,7 @@ func (s *ModelStore) Get(ctx context.Context, m GetModelI) (bool, error) {
 	}
 
 	if len(colNames) == 0 {
-		return false, fmt.Errorf("no columns")
+		return "", nil, nil, fmt.Errorf("no columns")
 	}
 
 	queryBuilder := s.QB.Select(colNames...).
@@ -377,18 +574,39 @@ func (s *ModelStore) Get(ctx context.Context, m GetModelI) (bool, error) {
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		return false, fmt.Errorf("fail to build query: %w", err)
+		return "", nil, nil, fmt.Errorf("fail to build query: %w", err)
 	}
 
-	err = con.QueryRow(ctx, query, args...).Scan(colFieldPointers...)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return false, nil
-		}
-		return false, fmt.Errorf("fail to query: %w", err)
+	return query, args, colFieldPointers, nil
+}
+
+// conditionPredicates converts ListParams-style conditions into squirrel predicates.
+func conditionPredicates(conditions map[string]any, conditionExpressions map[string][]any) []squirrel.Sqlizer {
+	result := make([]squirrel.Sqlizer, 0, 1+len(conditionExpressions))
+	if conditions != nil {
+		result = append(result, squirrel.Eq(conditions))
 	}
+	for expression, args := range conditionExpressions {
+		result = append(result, squirrel.Expr(expression, args...))
+	}
+	return result
+}
 
-	return true, nil
+// allowedColumnNames returns the requested columns present in allowedColMap, or all of them when none are requested.
+func allowedColumnNames(allowedColMap map[string]any, columns []string) []string {
+	result := make([]string, 0, len(columns))
+	if len(columns) > 0 {
+		for _, colName := range columns {
+			if _, ok := allowedColMap[colName]; ok {
+				result = append(result, colName)
+			}
+		}
+	} else {
+		for colName := range allowedColMap {
+			result = append(result, colName)
+		}
+	}
+	return result
 }
 
 func fieldPointersForColNames(m ListModelI, colNames []string) []any {
@@ -399,3 +617,110 @@ func fieldPointersForColNames(m ListModelI, colNames []string) []any {
 	}
 	return result
 }
+
+// onConflictForModel returns the model's OnConflict with the conflict target defaulted to PKColumnMap keys.
+func onConflictForModel(m UpdateCreateModelI) OnConflict {
+	var result OnConflict
+	if onConflictModel, ok := m.(WithOnConflictI); ok {
+		result = onConflictModel.OnConflict()
+	}
+
+	if result.isDefaultTarget() {
+		for k := range m.PKColumnMap() {
+			result.Columns = append(result.Columns, k)
+		}
+	}
+
+	return result
+}
+
+// isDefaultTarget reports whether the conflict target is left to the PKColumnMap keys.
+func (c OnConflict) isDefaultTarget() bool {
+	return c.Constraint == "" && len(c.Columns) == 0
+}
+
+func (c OnConflict) targetSQL() string {
+	if c.Constraint != "" {
+		return `ON CONSTRAINT ` + c.Constraint
+	}
+
+	result := `(` + strings.Join(c.Columns, ",") + `)`
+	if c.IndexPredicate != "" {
+		result += ` WHERE ` + c.IndexPredicate
+	}
+
+	return result
+}
+
+// columnNamesAndPointers splits a column map into column names and field pointers.
+func columnNamesAndPointers(colMap map[string]any) ([]string, []any) {
+	colNames := make([]string, 0, len(colMap))
+	fieldPointers := make([]any, 0, len(colMap))
+	for k, v := range colMap {
+		colNames = append(colNames, k)
+		fieldPointers = append(fieldPointers, v)
+	}
+
+	return colNames, fieldPointers
+}
+
+// returningColumns returns RETURNING column names and field pointers of m, if it implements WithReturningI.
+func returningColumns(m any) ([]string, []any) {
+	returningModel, ok := m.(WithReturningI)
+	if !ok {
+		return nil, nil
+	}
+
+	return columnNamesAndPointers(returningModel.WriteReturningColumnMap())
+}
+
+// execReturning executes query, scanning the first RETURNING row into fieldPointers if there are any.
+// It returns the number of affected rows from the command tag.
+func execReturning(ctx context.Context, con ConnectionI, query string, args []any, fieldPointers []any) (int64, error) {
+	if len(fieldPointers) > 0 {
+		rows, err := con.Query(ctx, query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("fail to query: %w", classifyError(err))
+		}
+
+		rowsAffected, err := scanFirstRow(rows, fieldPointers)
+		if err != nil {
+			return 0, err
+		}
+
+		return rowsAffected, nil
+	}
+
+	tag, err := con.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("fail to exec: %w", classifyError(err))
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// scanFirstRow scans the first row into fieldPointers, drains and closes rows
+// and returns the affected rows of the command tag.
+func scanFirstRow(rows pgx.Rows, fieldPointers []any) (int64, error) {
+	defer rows.Close()
+
+	scanned := false
+	for rows.Next() {
+		if scanned {
+			continue
+		}
+
+		err := rows.Scan(fieldPointers...)
+		if err != nil {
+			return 0, fmt.Errorf("fail to scan: %w", err)
+		}
+		scanned = true
+	}
+	// the command tag is complete only after the rows are closed
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+
+	return rows.CommandTag().RowsAffected(), nil
+}
@@ -0,0 +1,204 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+// Store is a typed wrapper of ModelStore for the list model T.
+// Reads return T values instead of filling callbacks. Create, Update, Delete and UpdateOrCreate take *T,
+// which must implement the model interface of the method, other mutators are the ones of the embedded ModelStore.
+type Store[T any, PT interface {
+	*T
+	ListModelI
+}] struct {
+	*ModelStore
+}
+
+func NewStore[T any, PT interface {
+	*T
+	ListModelI
+}](modelStore *ModelStore) *Store[T, PT] {
+	return &Store[T, PT]{
+		ModelStore: modelStore,
+	}
+}
+
+func (s *Store[T, PT]) List(ctx context.Context, params ListParams) ([]T, int64, error) {
+	var items []PT
+
+	totalCount, err := s.ModelStore.List(ctx, params, s.itemConstructor(&items))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return derefItems(items), totalCount, nil
+}
+
+func (s *Store[T, PT]) ListPage(ctx context.Context, params ListParams) ([]T, ListResult, error) {
+	var items []PT
+
+	result, err := s.ModelStore.ListPage(ctx, params, s.itemConstructor(&items))
+	if err != nil {
+		return nil, result, err
+	}
+
+	return derefItems(items), result, nil
+}
+
+func (s *Store[T, PT]) Iter(ctx context.Context, params ListParams) iter.Seq2[T, error] {
+	return ListIter[T, PT](ctx, s.ModelStore, params)
+}
+
+func (s *Store[T, PT]) ForEachChunk(ctx context.Context, params ListParams, chunkSize int, fn func(ctx context.Context, items []T) error) error {
+	return s.ModelStore.ForEachChunk(ctx, params, chunkSize, func() ListModelI {
+		return PT(new(T))
+	}, func(ctx context.Context, items []ListModelI) error {
+		result := make([]T, 0, len(items))
+		for _, item := range items {
+			result = append(result, *item.(PT))
+		}
+		return fn(ctx, result)
+	})
+}
+
+// Get is ModelStore.Get for T, which must implement GetModelI.
+// The pk values are assigned to the ListColumnMap fields of a new T, so they must be convertible to their types.
+func (s *Store[T, PT]) Get(ctx context.Context, pk map[string]any) (T, bool, error) {
+	var zero T
+
+	if len(pk) == 0 {
+		return zero, false, ErrEmptyConditions
+	}
+
+	item := PT(new(T))
+
+	getItem, ok := any(item).(GetModelI)
+	if !ok {
+		return zero, false, fmt.Errorf("%T does not implement GetModelI", item)
+	}
+
+	pkColMap := getItem.PKColumnMap()
+	if len(pk) != len(pkColMap) {
+		return zero, false, fmt.Errorf("pk columns do not match PKColumnMap")
+	}
+
+	colMap := item.ListColumnMap()
+	for colName, value := range pk {
+		if _, ok := pkColMap[colName]; !ok {
+			return zero, false, fmt.Errorf("column %q is not in PKColumnMap", colName)
+		}
+
+		fieldPointer, ok := colMap[colName]
+		if !ok {
+			return zero, false, fmt.Errorf("pk column %q is not in the list columns", colName)
+		}
+
+		field := reflect.ValueOf(fieldPointer).Elem()
+		v := reflect.ValueOf(value)
+		switch {
+		case v.IsValid() && v.Type().AssignableTo(field.Type()):
+			field.Set(v)
+		case v.IsValid() && isNumericKind(v.Kind()) && isNumericKind(field.Kind()):
+			field.Set(v.Convert(field.Type()))
+		default:
+			return zero, false, fmt.Errorf("pk column %q: %T is not assignable to %s", colName, value, field.Type())
+		}
+	}
+
+	found, err := s.ModelStore.Get(ctx, getItem)
+	if err != nil || !found {
+		return zero, false, err
+	}
+
+	return *item, true, nil
+}
+
+// GetMany is ModelStore.GetMany for T, which must implement GetModelI.
+func (s *Store[T, PT]) GetMany(ctx context.Context, params GetManyParams) ([]T, []map[string]any, error) {
+	if _, ok := any(PT(new(T))).(GetModelI); !ok {
+		return nil, nil, fmt.Errorf("%T does not implement GetModelI", PT(nil))
+	}
+
+	items, missingKeys, err := s.ModelStore.GetMany(ctx, params, func() GetModelI {
+		return any(PT(new(T))).(GetModelI)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		result = append(result, *item.(PT))
+	}
+
+	return result, missingKeys, nil
+}
+
+// Create is ModelStore.Create for T, which must implement CreateModelI.
+func (s *Store[T, PT]) Create(ctx context.Context, m PT) error {
+	createModel, ok := any(m).(CreateModelI)
+	if !ok {
+		return fmt.Errorf("%T does not implement CreateModelI", m)
+	}
+
+	return s.ModelStore.Create(ctx, createModel)
+}
+
+// Update is ModelStore.Update for T, which must implement UpdateModelI.
+func (s *Store[T, PT]) Update(ctx context.Context, m PT) error {
+	updateModel, ok := any(m).(UpdateModelI)
+	if !ok {
+		return fmt.Errorf("%T does not implement UpdateModelI", m)
+	}
+
+	return s.ModelStore.Update(ctx, updateModel)
+}
+
+// Delete is ModelStore.Delete for T, which must implement DeleteModelI.
+func (s *Store[T, PT]) Delete(ctx context.Context, m PT) error {
+	deleteModel, ok := any(m).(DeleteModelI)
+	if !ok {
+		return fmt.Errorf("%T does not implement DeleteModelI", m)
+	}
+
+	return s.ModelStore.Delete(ctx, deleteModel)
+}
+
+// UpdateOrCreate is ModelStore.UpdateOrCreate for T, which must implement UpdateCreateModelI.
+func (s *Store[T, PT]) UpdateOrCreate(ctx context.Context, m PT) error {
+	upsertModel, ok := any(m).(UpdateCreateModelI)
+	if !ok {
+		return fmt.Errorf("%T does not implement UpdateCreateModelI", m)
+	}
+
+	return s.ModelStore.UpdateOrCreate(ctx, upsertModel)
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// itemConstructor collects the added items as pointers: values scanned into a growing []T could be moved.
+func (s *Store[T, PT]) itemConstructor(items *[]PT) func(add bool) ListModelI {
+	return func(add bool) ListModelI {
+		item := PT(new(T))
+		if add {
+			*items = append(*items, item)
+		}
+		return item
+	}
+}
+
+func derefItems[T any, PT interface {
+	*T
+	ListModelI
+}](items []PT) []T {
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		result = append(result, *item)
+	}
+	return result
+}
@@ -0,0 +1,201 @@
+package mobone
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// tagMetaCache holds *tagMeta by struct type.
+var tagMetaCache sync.Map
+
+type tagField struct {
+	index     []int
+	column    string
+	pk        bool
+	readonly  bool
+	returning bool
+	omitempty bool
+}
+
+type tagMeta struct {
+	fields    []tagField
+	pkColumns []string
+}
+
+// TaggedModel implements the model interfaces for a struct described with `db` tags:
+//
+//	db:"name"           column, listed by ListColumnMap and written by Create/UpdateColumnMap
+//	db:"name,pk"        PKColumnMap and DefaultSortColumns, never updated
+//	db:"name,readonly"  only read, for example a serial id or a column with default now()
+//	db:"name,returning" scanned back by ReturningColumnMap after Create
+//	db:"name,omitempty" not written when zero, a non-nil pointer is written as its value
+//	db:"-"              ignored, as well as fields without the tag
+//
+// Embedded structs without a tag are flattened.
+type TaggedModel struct {
+	v    reflect.Value
+	meta *tagMeta
+}
+
+// TagModel wraps ptr, a pointer to a tagged struct. Field metadata is parsed once per type.
+// It panics when ptr is not a pointer to a struct or its tags are invalid.
+func TagModel(ptr any) *TaggedModel {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("mobone: TagModel expects a non-nil pointer to a struct, got %T", ptr))
+	}
+
+	return &TaggedModel{
+		v:    v.Elem(),
+		meta: tagMetaFor(v.Elem().Type()),
+	}
+}
+
+// Model returns the wrapped pointer.
+func (m *TaggedModel) Model() any {
+	return m.v.Addr().Interface()
+}
+
+func (m *TaggedModel) ListColumnMap() map[string]any {
+	result := make(map[string]any, len(m.meta.fields))
+	for _, f := range m.meta.fields {
+		result[f.column] = m.v.FieldByIndex(f.index).Addr().Interface()
+	}
+	return result
+}
+
+// DefaultSortColumns returns a copy, the metadata is shared by all models of the type.
+func (m *TaggedModel) DefaultSortColumns() []string {
+	return slices.Clone(m.meta.pkColumns)
+}
+
+func (m *TaggedModel) PKColumnMap() map[string]any {
+	result := make(map[string]any, len(m.meta.pkColumns))
+	for _, f := range m.meta.fields {
+		if f.pk {
+			result[f.column] = m.v.FieldByIndex(f.index).Interface()
+		}
+	}
+	return result
+}
+
+func (m *TaggedModel) CreateColumnMap() map[string]any {
+	return m.writeColumnMap(false)
+}
+
+func (m *TaggedModel) UpdateColumnMap() map[string]any {
+	return m.writeColumnMap(true)
+}
+
+func (m *TaggedModel) ReturningColumnMap() map[string]any {
+	var result map[string]any
+	for _, f := range m.meta.fields {
+		if f.returning {
+			if result == nil {
+				result = make(map[string]any)
+			}
+			result[f.column] = m.v.FieldByIndex(f.index).Addr().Interface()
+		}
+	}
+	return result
+}
+
+func (m *TaggedModel) writeColumnMap(update bool) map[string]any {
+	result := make(map[string]any, len(m.meta.fields))
+	for _, f := range m.meta.fields {
+		if f.readonly || update && f.pk {
+			continue
+		}
+
+		fv := m.v.FieldByIndex(f.index)
+		if f.omitempty {
+			if fv.IsZero() {
+				continue
+			}
+			if fv.Kind() == reflect.Pointer {
+				fv = fv.Elem()
+			}
+		}
+
+		result[f.column] = fv.Interface()
+	}
+	return result
+}
+
+func tagMetaFor(t reflect.Type) *tagMeta {
+	if meta, ok := tagMetaCache.Load(t); ok {
+		return meta.(*tagMeta)
+	}
+
+	meta := &tagMeta{}
+	parseTagFields(t, nil, meta)
+
+	if len(meta.fields) == 0 {
+		panic(fmt.Sprintf("mobone: %s has no db tagged fields", t))
+	}
+
+	columns := make(map[string]bool, len(meta.fields))
+	for _, f := range meta.fields {
+		if columns[f.column] {
+			panic(fmt.Sprintf("mobone: %s has duplicate column %q", t, f.column))
+		}
+		columns[f.column] = true
+
+		if f.pk {
+			meta.pkColumns = append(meta.pkColumns, f.column)
+		}
+	}
+
+	actual, _ := tagMetaCache.LoadOrStore(t, meta)
+
+	return actual.(*tagMeta)
+}
+
+func parseTagFields(t reflect.Type, index []int, meta *tagMeta) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		tag, hasTag := sf.Tag.Lookup("db")
+		if !hasTag {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				parseTagFields(sf.Type, fieldIndex, meta)
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			panic(fmt.Sprintf("mobone: %s.%s is tagged but not exported", t, sf.Name))
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			panic(fmt.Sprintf("mobone: %s.%s has an empty column name", t, sf.Name))
+		}
+
+		f := tagField{index: fieldIndex, column: name}
+		if options != "" {
+			for _, option := range strings.Split(options, ",") {
+				switch option {
+				case "pk":
+					f.pk = true
+				case "readonly":
+					f.readonly = true
+				case "returning":
+					f.returning = true
+				case "omitempty":
+					f.omitempty = true
+				default:
+					panic(fmt.Sprintf("mobone: %s.%s has unknown db tag option %q", t, sf.Name, option))
+				}
+			}
+		}
+
+		meta.fields = append(meta.fields, f)
+	}
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestBatch(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+", "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	modelStore := &mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+
+	stockStore := &mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          stockTableName,
+	}
+
+	name := "Test Model"
+	existingModel := &model.Upsert{Name: &name}
+	err = modelStore.Create(bgCtx, existingModel)
+	require.NoError(t, err)
+
+	newName := "Test Model changed"
+	createModel := &model.Upsert{Name: &name}
+	stockModel := &model.StockUpsert{Sku: "sku-1", WarehouseId: 1, Qty: 1}
+	dbItem := &model.Select{Id: existingModel.PKId}
+	missingItem := &model.Select{Id: 100}
+
+	b := mobone.NewBatch()
+	createResult := b.Create(modelStore, createModel)
+	stockResult := b.Create(stockStore, stockModel)
+	updateResult := b.Update(modelStore, &model.Upsert{PKId: existingModel.PKId, Name: &newName})
+	getResult := b.Get(modelStore, dbItem)
+	missingResult := b.Get(modelStore, missingItem)
+	deleteResult := b.Delete(modelStore, &model.Upsert{PKId: 100})
+	require.Equal(t, 6, b.Len())
+
+	err = b.Send(bgCtx)
+	require.NoError(t, err)
+
+	require.NoError(t, createResult.Err)
+	require.EqualValues(t, 1, createResult.RowsAffected)
+	require.Equal(t, existingModel.PKId+1, createModel.PKId)
+	require.NoError(t, stockResult.Err)
+	require.EqualValues(t, 1, stockResult.RowsAffected)
+	require.NoError(t, updateResult.Err)
+	require.EqualValues(t, 1, updateResult.RowsAffected)
+	require.NoError(t, getResult.Err)
+	require.True(t, getResult.Found)
+	require.Equal(t, newName, dbItem.Name)
+	require.NoError(t, missingResult.Err)
+	require.False(t, missingResult.Found)
+	require.NoError(t, deleteResult.Err)
+	require.EqualValues(t, 0, deleteResult.RowsAffected)
+	require.Equal(t, 0, b.Len())
+
+	// a sent batch does not replay its operations
+	err = b.Send(bgCtx)
+	require.NoError(t, err)
+
+	// a store of another connection source is rejected
+	otherStore := &mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: stockTableName,
+	}
+	b = mobone.NewBatch()
+	b.Get(modelStore, &model.Select{Id: existingModel.PKId})
+	otherResult := b.Create(otherStore, &model.StockUpsert{Sku: "sku-2", WarehouseId: 1})
+	require.ErrorIs(t, otherResult.Err, mobone.ErrBatchStoreMismatch)
+	require.Equal(t, 1, b.Len())
+	err = b.Send(bgCtx)
+	require.ErrorIs(t, err, mobone.ErrBatchStoreMismatch)
+
+	// transaction managers that are not comparable are told apart without a panic
+	b = mobone.NewBatch()
+	b.Get(&mobone.ModelStore{Con: dbCon.pool, TransactionManager: tagsTxM{txM: txM}, QB: queryBuilder, TableName: tableName}, &model.Select{Id: existingModel.PKId})
+	mismatchResult := b.Get(&mobone.ModelStore{Con: dbCon.pool, TransactionManager: tagsTxM{txM: txM}, QB: queryBuilder, TableName: tableName}, &model.Select{Id: existingModel.PKId})
+	require.ErrorIs(t, mismatchResult.Err, mobone.ErrBatchStoreMismatch)
+
+	// RequireRowsAffected applies to batched Update and Delete as well
+	requiredStore := &mobone.ModelStore{
+		Con:                 dbCon.pool,
+		TransactionManager:  txM,
+		QB:                  queryBuilder,
+		TableName:           tableName,
+		RequireRowsAffected: true,
+	}
+	b = mobone.NewBatch()
+	updateResult = b.Update(requiredStore, &model.Upsert{PKId: existingModel.PKId, Name: &name})
+	deleteResult = b.Delete(requiredStore, &model.Upsert{PKId: 100})
+	err = b.Send(bgCtx)
+	require.ErrorIs(t, err, mobone.ErrNotFound)
+	require.NoError(t, updateResult.Err)
+	require.ErrorIs(t, deleteResult.Err, mobone.ErrNotFound)
+
+	// inside a transaction, a failed operation reports its own error
+	txFnErr := txM.TxFn(bgCtx, func(ctx context.Context) error {
+		b := mobone.NewBatch()
+		okResult := b.Create(modelStore, &model.Upsert{Name: &name})
+		duplicateResult := b.Create(stockStore, &model.StockUpsert{Sku: "sku-1", WarehouseId: 1})
+		err := b.Send(ctx)
+
+		require.NoError(t, okResult.Err)
+		require.ErrorIs(t, duplicateResult.Err, mobone.ErrUniqueViolation)
+
+		return err
+	})
+	require.ErrorIs(t, txFnErr, mobone.ErrUniqueViolation)
+}
+
+// tagsTxM is a transaction manager of a type that is not comparable
+type tagsTxM struct {
+	txM  *mobone.TransactionManager
+	tags []string
+}
+
+func (m tagsTxM) GetConnection(ctx context.Context) mobone.ConnectionI {
+	return m.txM.GetConnection(ctx)
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestCreateMany(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	name1 := "Test Model 1"
+	name3 := "Test Model 3"
+	flag := true
+
+	createModels := []*model.UpsertReturning{
+		{Upsert: model.Upsert{Name: &name1}},
+		{Upsert: model.Upsert{Flag: &flag}},
+		{Upsert: model.Upsert{Name: &name3, Flag: &flag}},
+	}
+
+	ms := make([]mobone.CreateModelI, 0, len(createModels))
+	for _, m := range createModels {
+		ms = append(ms, m)
+	}
+
+	err = modelStore.CreateMany(ctx, ms)
+	require.NoError(t, err)
+
+	for i, m := range createModels {
+		require.Equal(t, i+1, m.Result.Id)
+	}
+	require.Equal(t, name1, createModels[0].Result.Name)
+	require.False(t, createModels[0].Result.Flag)
+	require.Equal(t, "", createModels[1].Result.Name)
+	require.True(t, createModels[1].Result.Flag)
+	require.Equal(t, name3, createModels[2].Result.Name)
+	require.True(t, createModels[2].Result.Flag)
+
+	// chunks over the bind parameter limit
+	ms = ms[:0]
+	for range 40000 {
+		ms = append(ms, &model.Upsert{Name: &name1, Flag: &flag})
+	}
+	err = modelStore.CreateMany(ctx, ms)
+	require.NoError(t, err)
+	require.Equal(t, 40003, ms[len(ms)-1].(*model.Upsert).PKId)
+
+	listCount, err := modelStore.List(ctx, mobone.ListParams{
+		OnlyCount: true,
+	}, func(add bool) mobone.ListModelI {
+		return &model.Select{}
+	})
+	require.NoError(t, err)
+	require.Equal(t, 40003, int(listCount))
+}
+
+func TestUpdateOrCreateMany(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: stockTableName,
+	}
+
+	now := time.Now()
+
+	err = modelStore.UpdateOrCreate(ctx, &model.StockUpsert{Sku: "sku-1", WarehouseId: 1, Qty: 1, UpdatedAt: now})
+	require.NoError(t, err)
+	err = modelStore.UpdateOrCreate(ctx, &model.StockUpsert{Sku: "sku-2", WarehouseId: 1, Qty: 2, UpdatedAt: now})
+	require.NoError(t, err)
+
+	results, err := modelStore.UpdateOrCreateMany(ctx, []mobone.UpdateCreateModelI{
+		&model.StockUpsert{Sku: "sku-1", WarehouseId: 1, Qty: 10, UpdatedAt: now.Add(time.Minute)},  // updated
+		&model.StockUpsert{Sku: "sku-2", WarehouseId: 1, Qty: 20, UpdatedAt: now.Add(-time.Minute)}, // stale, skipped
+		&model.StockUpsert{Sku: "sku-3", WarehouseId: 1, Qty: 30, UpdatedAt: now},                   // inserted
+		&model.StockUpsert{Sku: "sku-3", WarehouseId: 2, Qty: 40, UpdatedAt: now},                   // inserted
+	})
+	require.NoError(t, err)
+	require.Equal(t, []mobone.UpsertBatchResult{{Inserted: 2, Updated: 1, Skipped: 1}}, results)
+
+	items := make([]*model.Stock, 0, 4)
+	_, err = modelStore.List(ctx, mobone.ListParams{
+		Sort: []string{"sku", "warehouse_id"},
+	}, func(add bool) mobone.ListModelI {
+		x := &model.Stock{}
+		if add {
+			items = append(items, x)
+		}
+		return x
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	require.Equal(t, []int{10, 2, 30, 40}, []int{items[0].Qty, items[1].Qty, items[2].Qty, items[3].Qty})
+
+	// conflict clauses of the same SQL with different arguments are not merged
+	results, err = modelStore.UpdateOrCreateMany(ctx, []mobone.UpdateCreateModelI{
+		&stockUpsertWhere{
+			StockUpsert: model.StockUpsert{Sku: "sku-3", WarehouseId: 1, Qty: 31, UpdatedAt: now},
+			where:       squirrel.Expr("t.sku = ?::text and ?::text = ''", "sku-3", ""),
+		},
+		&stockUpsertWhere{
+			StockUpsert: model.StockUpsert{Sku: "sku-3", WarehouseId: 2, Qty: 41, UpdatedAt: now},
+			where:       squirrel.Expr("t.sku = ?::text and ?::text = ''", "sku-", "3"),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []mobone.UpsertBatchResult{{Updated: 1}, {Skipped: 1}}, results)
+
+	// jsonb merge expressions are batched through EXCLUDED
+	_, err = dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	tableStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	email := "test@example.com"
+	results, err = tableStore.UpdateOrCreateMany(ctx, []mobone.UpdateCreateModelI{
+		&model.Upsert{Contact: &model.ContactEdit{Email: &email}},
+		&model.Upsert{Contact: &model.ContactEdit{Email: &email}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []mobone.UpsertBatchResult{{Inserted: 2}}, results)
+}
+
+// stockUpsertWhere limits the update of a stock upsert by its own condition
+type stockUpsertWhere struct {
+	model.StockUpsert
+	where squirrel.Sqlizer
+}
+
+func (m *stockUpsertWhere) OnConflict() mobone.OnConflict {
+	return mobone.OnConflict{
+		Columns:     []string{"sku", "warehouse_id"},
+		UpdateWhere: m.where,
+	}
+}
+
+func TestUpdateMany(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	ms := make([]mobone.CreateModelI, 0, 4)
+	for range 4 {
+		name := "Test Model"
+		ms = append(ms, &model.Upsert{Name: &name})
+	}
+	err = modelStore.CreateMany(ctx, ms)
+	require.NoError(t, err)
+
+	names := []string{"Name 1", "Name 2", "Name 3"}
+	flag := true
+	updatedAt := time.Now().Add(-time.Hour)
+
+	results, err := modelStore.UpdateMany(ctx, []mobone.UpdateModelI{
+		&model.Upsert{PKId: 1, Name: &names[0]},
+		&model.Upsert{PKId: 2, Name: &names[1], Flag: &flag, UpdatedAt: &updatedAt},
+		&model.Upsert{PKId: 3, Name: &names[2]},
+		&model.Upsert{PKId: 100, Name: &names[2]},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []mobone.UpdateManyResult{
+		{Columns: []string{"name"}, RowsAffected: 2},
+		{Columns: []string{"flag", "name", "updated_at"}, RowsAffected: 1},
+	}, results)
+
+	dbItems := make([]*model.Select, 0, 4)
+	_, err = modelStore.List(ctx, mobone.ListParams{}, func(add bool) mobone.ListModelI {
+		x := &model.Select{}
+		if add {
+			dbItems = append(dbItems, x)
+		}
+		return x
+	})
+	require.NoError(t, err)
+	require.Len(t, dbItems, 4)
+	require.Equal(t, []string{"Name 1", "Name 2", "Name 3", "Test Model"}, []string{dbItems[0].Name, dbItems[1].Name, dbItems[2].Name, dbItems[3].Name})
+	require.True(t, dbItems[1].Flag)
+	require.WithinDuration(t, updatedAt, dbItems[1].UpdatedAt, time.Millisecond)
+
+	// expressions can not be batched
+	email := "test@example.com"
+	_, err = modelStore.UpdateMany(ctx, []mobone.UpdateModelI{
+		&model.Upsert{PKId: 1, Contact: &model.ContactEdit{Email: &email}},
+	})
+	require.ErrorContains(t, err, "expression")
+}
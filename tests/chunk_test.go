@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestForEachChunk(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	stockStore := &mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          stockTableName,
+	}
+
+	for i := 1; i <= 10; i++ {
+		err = stockStore.Create(bgCtx, &model.StockUpsert{Sku: "sku-1", WarehouseId: i, Qty: i})
+		require.NoError(t, err)
+	}
+
+	newItem := func() mobone.ListModelI {
+		return &model.Stock{}
+	}
+
+	// chunks of the filtered query in sort order
+	var chunks [][]int
+	err = stockStore.ForEachChunk(bgCtx, mobone.ListParams{
+		ConditionExpressions: map[string][]any{"qty > ?": {2}},
+		Sort:                 []string{"id desc"},
+	}, 3, newItem, func(ctx context.Context, items []mobone.ListModelI) error {
+		var ids []int
+		for _, item := range items {
+			ids = append(ids, item.(*model.Stock).Id)
+		}
+		chunks = append(chunks, ids)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{10, 9, 8}, {7, 6, 5}, {4, 3}}, chunks)
+	requireNoActiveTransactions(t)
+
+	// callback error stops the iteration
+	errStop := errors.New("stop")
+	calls := 0
+	err = stockStore.ForEachChunk(bgCtx, mobone.ListParams{}, 4, newItem, func(ctx context.Context, items []mobone.ListModelI) error {
+		calls++
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, 1, calls)
+	requireNoActiveTransactions(t)
+
+	// cancellation
+	ctx, cancel := context.WithCancel(bgCtx)
+	calls = 0
+	err = stockStore.ForEachChunk(ctx, mobone.ListParams{}, 4, newItem, func(ctx context.Context, items []mobone.ListModelI) error {
+		calls++
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, calls)
+	requireNoActiveTransactions(t)
+
+	// inside TxFn the callback can use the same transaction
+	err = txM.TxFn(bgCtx, func(ctx context.Context) error {
+		return stockStore.ForEachChunk(ctx, mobone.ListParams{}, 5, newItem, func(ctx context.Context, items []mobone.ListModelI) error {
+			for _, item := range items {
+				_, err := stockStore.UpdateWhere(ctx, map[string]any{"qty": 0}, mobone.WhereParams{
+					Conditions: map[string]any{"id": item.(*model.Stock).Id},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	require.NoError(t, err)
+
+	count, err := stockStore.List(bgCtx, mobone.ListParams{
+		Conditions: map[string]any{"qty": 0},
+		OnlyCount:  true,
+	}, func(add bool) mobone.ListModelI {
+		return &model.Stock{}
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 10, count)
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func stockSeq(n, qty int, updatedAt time.Time) iter.Seq[mobone.CreateModelI] {
+	return func(yield func(mobone.CreateModelI) bool) {
+		for i := range n {
+			if !yield(&model.StockUpsert{Sku: fmt.Sprintf("sku-%d", i), WarehouseId: 1, Qty: qty, UpdatedAt: updatedAt}) {
+				return
+			}
+		}
+	}
+}
+
+func TestCopyIn(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	modelStore := mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          stockTableName,
+	}
+
+	columns := []string{"sku", "warehouse_id", "qty", "updated_at"}
+	now := time.Now()
+
+	count, err := modelStore.CopyIn(bgCtx, columns, stockSeq(1000, 1, now))
+	require.NoError(t, err)
+	require.EqualValues(t, 1000, count)
+
+	// inside a transaction
+	txFnErr := txM.TxFn(bgCtx, func(ctx context.Context) error {
+		count, err := modelStore.CopyIn(ctx, []string{"sku", "warehouse_id"}, func(yield func(mobone.CreateModelI) bool) {
+			yield(&model.StockUpsert{Sku: "sku-tx", WarehouseId: 2})
+		})
+		if err != nil {
+			return err
+		}
+		require.EqualValues(t, 1, count)
+
+		return fmt.Errorf("test error")
+	})
+	require.ErrorContains(t, txFnErr, "test error")
+
+	listCount, err := modelStore.List(bgCtx, mobone.ListParams{
+		OnlyCount: true,
+	}, func(add bool) mobone.ListModelI {
+		return &model.Stock{}
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1000, int(listCount))
+
+	// staging table + merge
+	onConflict := mobone.OnConflict{Columns: []string{"sku", "warehouse_id"}}
+
+	count, err = modelStore.CopyInMerge(bgCtx, columns, onConflict, stockSeq(1500, 2, now))
+	require.NoError(t, err)
+	require.EqualValues(t, 1500, count)
+
+	txFnErr = txM.TxFn(bgCtx, func(ctx context.Context) error {
+		// twice in one transaction, staging tables must not collide
+		for range 2 {
+			_, err := modelStore.CopyInMerge(ctx, columns, onConflict, stockSeq(10, 3, now))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, txFnErr)
+
+	items := make([]*model.Stock, 0, 1500)
+	_, err = modelStore.List(bgCtx, mobone.ListParams{}, func(add bool) mobone.ListModelI {
+		x := &model.Stock{}
+		if add {
+			items = append(items, x)
+		}
+		return x
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 1500)
+	require.Equal(t, 3, items[0].Qty)
+	require.Equal(t, 2, items[1499].Qty)
+
+	// missing columns are reported
+	_, err = modelStore.CopyIn(bgCtx, []string{"sku", "unknown"}, stockSeq(1, 1, now))
+	require.ErrorContains(t, err, "unknown")
+}
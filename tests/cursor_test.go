@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestListPage(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	stockStore := mobone.ModelStore{
+		Con:          dbCon.pool,
+		QB:           queryBuilder,
+		TableName:    stockTableName,
+		CursorSecret: []byte("secret"),
+	}
+
+	// ids 1..7, skus with duplicates to check the pk tie-breaker
+	updatedAt := time.Date(2024, 1, 1, 0, 0, 0, 123456000, time.UTC)
+	for i, sku := range []string{"b", "a", "c", "b", "a", "c", "b"} {
+		err = stockStore.Create(ctx, &model.StockUpsert{Sku: sku, WarehouseId: i, UpdatedAt: updatedAt.Add(time.Duration(i%3) * time.Microsecond)})
+		require.NoError(t, err)
+	}
+
+	listPage := func(params mobone.ListParams) ([]int, mobone.ListResult) {
+		var items []*model.Stock
+		result, err := stockStore.ListPage(ctx, params, func(add bool) mobone.ListModelI {
+			item := &model.Stock{}
+			if add {
+				items = append(items, item)
+			}
+			return item
+		})
+		require.NoError(t, err)
+		return stockIds(items), result
+	}
+
+	sort := []string{"sku desc"}
+
+	// forward
+	ids, result := listPage(mobone.ListParams{Sort: sort, PageSize: 3, WithTotalCount: true})
+	require.Equal(t, []int{3, 6, 1}, ids)
+	require.EqualValues(t, 7, result.TotalCount)
+	require.NotEmpty(t, result.NextCursor)
+	require.Empty(t, result.PrevCursor)
+
+	ids, result = listPage(mobone.ListParams{Sort: sort, PageSize: 3, After: result.NextCursor})
+	require.Equal(t, []int{4, 7, 2}, ids)
+	require.NotEmpty(t, result.NextCursor)
+	require.NotEmpty(t, result.PrevCursor)
+	page2 := result
+
+	ids, result = listPage(mobone.ListParams{Sort: sort, PageSize: 3, After: result.NextCursor})
+	require.Equal(t, []int{5}, ids)
+	require.Empty(t, result.NextCursor)
+	require.NotEmpty(t, result.PrevCursor)
+
+	// backward
+	ids, result = listPage(mobone.ListParams{Sort: sort, PageSize: 3, Before: result.PrevCursor})
+	require.Equal(t, []int{4, 7, 2}, ids)
+	require.Equal(t, page2.NextCursor, result.NextCursor)
+	require.Equal(t, page2.PrevCursor, result.PrevCursor)
+
+	ids, result = listPage(mobone.ListParams{Sort: sort, PageSize: 3, Before: result.PrevCursor})
+	require.Equal(t, []int{3, 6, 1}, ids)
+	require.NotEmpty(t, result.NextCursor)
+	require.Empty(t, result.PrevCursor)
+
+	// timestamp sort with conditions, List accepts cursors too
+	timeSort := []string{"updated_at", "id desc"}
+	ids, result = listPage(mobone.ListParams{Sort: timeSort, PageSize: 2, Conditions: map[string]any{"sku": []string{"a", "b"}}})
+	require.Equal(t, []int{7, 4}, ids)
+
+	var items []*model.Stock
+	_, err = stockStore.List(ctx, mobone.ListParams{Sort: timeSort, PageSize: 2, Conditions: map[string]any{"sku": []string{"a", "b"}}, After: result.NextCursor}, func(add bool) mobone.ListModelI {
+		item := &model.Stock{}
+		if add {
+			items = append(items, item)
+		}
+		return item
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 5}, stockIds(items))
+
+	// tampered and foreign cursors
+	cursor := page2.NextCursor
+	tampered := "A" + cursor[1:]
+	if cursor[0] == 'A' {
+		tampered = "B" + cursor[1:]
+	}
+	for _, c := range []string{"garbage", tampered, result.NextCursor} {
+		_, err = stockStore.ListPage(ctx, mobone.ListParams{Sort: sort, PageSize: 3, After: c}, func(add bool) mobone.ListModelI {
+			return &model.Stock{}
+		})
+		require.ErrorIs(t, err, mobone.ErrInvalidCursor, fmt.Sprintf("cursor %q", c))
+	}
+
+	otherStore := stockStore
+	otherStore.CursorSecret = []byte("other")
+	_, err = otherStore.ListPage(ctx, mobone.ListParams{Sort: sort, PageSize: 3, After: page2.NextCursor}, func(add bool) mobone.ListModelI {
+		return &model.Stock{}
+	})
+	require.ErrorIs(t, err, mobone.ErrInvalidCursor)
+}
+
+func stockIds(items []*model.Stock) []int {
+	result := make([]int, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.Id)
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+type rawCreate map[string]any
+
+func (m rawCreate) CreateColumnMap() map[string]any {
+	return m
+}
+
+func (m rawCreate) ReturningColumnMap() map[string]any {
+	return nil
+}
+
+func TestTypedErrors(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	err = modelStore.Create(ctx, rawCreate{"id": 1})
+	require.NoError(t, err)
+
+	err = modelStore.Create(ctx, rawCreate{"id": 1})
+	require.ErrorIs(t, err, mobone.ErrUniqueViolation)
+	require.ErrorContains(t, err, "fail to exec")
+
+	var dbErr *mobone.DBError
+	require.ErrorAs(t, err, &dbErr)
+	require.Equal(t, "23505", dbErr.Code)
+	require.Equal(t, tableName+"_pkey", dbErr.Constraint)
+	require.Equal(t, tableName, dbErr.Table)
+
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+
+	err = modelStore.Create(ctx, rawCreate{"id": 2, "name": nil})
+	require.ErrorIs(t, err, mobone.ErrNotNullViolation)
+	require.ErrorAs(t, err, &dbErr)
+	require.Equal(t, "name", dbErr.Column)
+	require.NotErrorIs(t, err, mobone.ErrUniqueViolation)
+}
+
+func TestRowsAffected(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	name := "Test Model"
+	createModel := &model.Upsert{Name: &name}
+	err = modelStore.Create(ctx, createModel)
+	require.NoError(t, err)
+
+	newName := "Test Model changed"
+	rowsAffected, err := modelStore.UpdateRows(ctx, &model.Upsert{PKId: createModel.PKId, Name: &newName})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rowsAffected)
+
+	rowsAffected, err = modelStore.UpdateRows(ctx, &model.Upsert{PKId: createModel.PKId + 1, Name: &newName})
+	require.NoError(t, err)
+	require.EqualValues(t, 0, rowsAffected)
+
+	// missing rows are not an error by default
+	err = modelStore.Update(ctx, &model.Upsert{PKId: createModel.PKId + 1, Name: &newName})
+	require.NoError(t, err)
+
+	modelStore.RequireRowsAffected = true
+
+	err = modelStore.Update(ctx, &model.Upsert{PKId: createModel.PKId + 1, Name: &newName})
+	require.ErrorIs(t, err, mobone.ErrNotFound)
+
+	err = modelStore.Delete(ctx, &model.Upsert{PKId: createModel.PKId + 1})
+	require.ErrorIs(t, err, mobone.ErrNotFound)
+
+	rowsAffected, err = modelStore.DeleteRows(ctx, &model.Upsert{PKId: createModel.PKId})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rowsAffected)
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestGeneratedModel(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	item := &model.Generated{Name: "Name 1", Flag: true, Contact: model.Contact{Phone: "123"}}
+	err = modelStore.Create(ctx, item)
+	require.NoError(t, err)
+	require.Equal(t, 1, item.Id)
+	require.False(t, item.CreatedAt.IsZero())
+
+	// only non-nil pointer fields are updated
+	name := "Name 1 changed"
+	err = modelStore.Update(ctx, &model.GeneratedEdit{Id: item.Id, Name: &name})
+	require.NoError(t, err)
+
+	var items []*model.Generated
+	_, err = modelStore.List(ctx, mobone.ListParams{
+		Conditions: map[string]any{model.GeneratedColumnFlag: true},
+		Sort:       []string{model.GeneratedColumnId + " desc"},
+	}, func(add bool) mobone.ListModelI {
+		it := &model.Generated{}
+		if add {
+			items = append(items, it)
+		}
+		return it
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Name 1 changed", items[0].Name)
+	require.True(t, items[0].Flag)
+	require.Equal(t, "123", items[0].Contact.Phone)
+
+	dbItem := &model.Generated{Id: item.Id}
+	found, err := modelStore.Get(ctx, dbItem)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, item.CreatedAt.UnixMicro(), dbItem.CreatedAt.UnixMicro())
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestGetMany(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+", "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	names := []string{"Name 1", "Name 2", "Name 3"}
+	for i := range names {
+		err = modelStore.Create(ctx, &model.Upsert{Name: &names[i]})
+		require.NoError(t, err)
+	}
+
+	items, missingKeys, err := modelStore.GetMany(ctx, mobone.GetManyParams{
+		Keys:      []map[string]any{{"id": 3}, {"id": 100}, {"id": 1}, {"id": 3}},
+		KeepOrder: true,
+	}, func() mobone.GetModelI {
+		return &model.Select{}
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "Name 3", items[0].(*model.Select).Name)
+	require.Equal(t, "Name 1", items[1].(*model.Select).Name)
+	require.Equal(t, []map[string]any{{"id": 100}}, missingKeys)
+
+	// keys are matched by PostgreSQL, equal values of other types find the same row once
+	id := int64(2)
+	items, missingKeys, err = modelStore.GetMany(ctx, mobone.GetManyParams{
+		Keys:      []map[string]any{{"id": &id}, {"id": 100}, {"id": int16(2)}, {"id": 100}},
+		KeepOrder: true,
+	}, func() mobone.GetModelI {
+		return &model.Select{}
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Name 2", items[0].(*model.Select).Name)
+	require.Equal(t, []map[string]any{{"id": 100}, {"id": 100}}, missingKeys)
+
+	// composite keys
+	stockStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: stockTableName,
+	}
+
+	for _, m := range []*model.StockUpsert{
+		{Sku: "sku-1", WarehouseId: 1, Qty: 1},
+		{Sku: "sku-1", WarehouseId: 2, Qty: 2},
+		{Sku: "sku-2", WarehouseId: 1, Qty: 3},
+	} {
+		err = stockStore.Create(ctx, m)
+		require.NoError(t, err)
+	}
+
+	items, missingKeys, err = stockStore.GetMany(ctx, mobone.GetManyParams{
+		Keys: []map[string]any{
+			{"sku": "sku-2", "warehouse_id": 1},
+			{"sku": "sku-2", "warehouse_id": 2},
+			{"sku": "sku-1", "warehouse_id": 2},
+		},
+		KeepOrder: true,
+	}, func() mobone.GetModelI {
+		return &stockByKey{}
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, 3, items[0].(*stockByKey).Qty)
+	require.Equal(t, 2, items[1].(*stockByKey).Qty)
+	require.Equal(t, []map[string]any{{"sku": "sku-2", "warehouse_id": 2}}, missingKeys)
+}
+
+// stockByKey identifies stock rows by their natural key
+type stockByKey struct {
+	model.Stock
+}
+
+func (m *stockByKey) PKColumnMap() map[string]any {
+	return map[string]any{
+		"sku":          m.Sku,
+		"warehouse_id": m.WarehouseId,
+	}
+}
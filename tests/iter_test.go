@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestListIter(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	stockStore := &mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          stockTableName,
+	}
+
+	for i := 1; i <= 5; i++ {
+		err = stockStore.Create(bgCtx, &model.StockUpsert{Sku: "sku-1", WarehouseId: i, Qty: i})
+		require.NoError(t, err)
+	}
+
+	// full iteration with conditions and sort
+	var ids []int
+	for item, err := range mobone.ListIter[model.Stock](bgCtx, stockStore, mobone.ListParams{
+		ConditionExpressions: map[string][]any{"qty > ?": {1}},
+		Sort:                 []string{"id desc"},
+	}) {
+		require.NoError(t, err)
+		ids = append(ids, item.Id)
+	}
+	require.Equal(t, []int{5, 4, 3, 2}, ids)
+
+	// early break releases the connection
+	for range 20 {
+		for item, err := range mobone.ListIter[model.Stock](bgCtx, stockStore, mobone.ListParams{}) {
+			require.NoError(t, err)
+			require.Equal(t, 1, item.Id)
+			break
+		}
+	}
+	require.Zero(t, dbCon.pool.Stat().AcquiredConns())
+
+	// inside TxFn uncommitted rows are visible
+	err = txM.TxFn(bgCtx, func(ctx context.Context) error {
+		err := stockStore.Create(ctx, &model.StockUpsert{Sku: "sku-2", WarehouseId: 1, Qty: 10})
+		require.NoError(t, err)
+
+		var skus []string
+		for item, err := range mobone.ListIter[model.Stock](ctx, stockStore, mobone.ListParams{
+			Conditions: map[string]any{"qty": 10},
+		}) {
+			require.NoError(t, err)
+			skus = append(skus, item.Sku)
+		}
+		require.Equal(t, []string{"sku-2"}, skus)
+
+		// the transaction is still usable after the loop
+		return stockStore.Delete(ctx, &model.Stock{Id: 6})
+	})
+	require.NoError(t, err)
+	requireNoActiveTransactions(t)
+
+	// query errors are yielded
+	var iterErr error
+	for _, err := range mobone.ListIter[model.Stock](bgCtx, stockStore, mobone.ListParams{
+		ConditionExpressions: map[string][]any{"no_such_column = ?": {1}},
+	}) {
+		iterErr = err
+	}
+	require.Error(t, iterErr)
+}
@@ -16,6 +16,7 @@ import (
 )
 
 const tableName = "tests"
+const stockTableName = "tests_stock"
 
 var dbCon *Con
 var queryBuilder = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
@@ -94,6 +95,20 @@ func initSchema(con *Con) error {
 		return fmt.Errorf("unable to create table: %w", err)
 	}
 
+	_, err = con.pool.Exec(ctx, `
+		CREATE TABLE `+stockTableName+` (
+		    id SERIAL PRIMARY KEY,
+		    sku text not null,
+		    warehouse_id int not null,
+		    qty int not null default 0,
+		    updated_at timestamptz not null default now(),
+		    constraint `+stockTableName+`_sku_warehouse_key unique (sku, warehouse_id)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("unable to create stock table: %w", err)
+	}
+
 	return nil
 }
 
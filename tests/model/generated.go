@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+//go:generate go run ../../cmd/mobone-gen -type Generated,GeneratedEdit
+
+type Generated struct {
+	Id        int       `db:"id,pk,readonly,returning"`
+	CreatedAt time.Time `db:"created_at,readonly,returning"`
+	Name      string    `db:"name"`
+	Flag      bool      `db:"flag,omitempty"`
+	Contact   Contact   `db:"contact,omitempty"`
+}
+
+type GeneratedEdit struct {
+	Id      int          `db:"id,pk"`
+	Name    *string      `db:"name"`
+	Flag    *bool        `db:"flag"`
+	Contact *ContactEdit `db:"contact"`
+}
@@ -0,0 +1,138 @@
+// Code generated by mobone-gen. DO NOT EDIT.
+
+package model
+
+const (
+	GeneratedColumnId        = "id"
+	GeneratedColumnCreatedAt = "created_at"
+	GeneratedColumnName      = "name"
+	GeneratedColumnFlag      = "flag"
+	GeneratedColumnContact   = "contact"
+)
+
+func (m *Generated) ListColumnMap() map[string]any {
+	return map[string]any{
+		GeneratedColumnId:        &m.Id,
+		GeneratedColumnCreatedAt: &m.CreatedAt,
+		GeneratedColumnName:      &m.Name,
+		GeneratedColumnFlag:      &m.Flag,
+		GeneratedColumnContact:   &m.Contact,
+	}
+}
+
+func (m *Generated) DefaultSortColumns() []string {
+	return []string{GeneratedColumnId}
+}
+
+func (m *Generated) PKColumnMap() map[string]any {
+	return map[string]any{
+		GeneratedColumnId: m.Id,
+	}
+}
+
+func (m *Generated) CreateColumnMap() map[string]any {
+	result := make(map[string]any, 3)
+
+	result[GeneratedColumnName] = m.Name
+
+	if m.Flag {
+		result[GeneratedColumnFlag] = m.Flag
+	}
+
+	if m.Contact != (Contact{}) {
+		result[GeneratedColumnContact] = m.Contact
+	}
+
+	return result
+}
+
+func (m *Generated) UpdateColumnMap() map[string]any {
+	result := make(map[string]any, 3)
+
+	result[GeneratedColumnName] = m.Name
+
+	if m.Flag {
+		result[GeneratedColumnFlag] = m.Flag
+	}
+
+	if m.Contact != (Contact{}) {
+		result[GeneratedColumnContact] = m.Contact
+	}
+
+	return result
+}
+
+func (m *Generated) ReturningColumnMap() map[string]any {
+	return map[string]any{
+		GeneratedColumnId:        &m.Id,
+		GeneratedColumnCreatedAt: &m.CreatedAt,
+	}
+}
+
+const (
+	GeneratedEditColumnId      = "id"
+	GeneratedEditColumnName    = "name"
+	GeneratedEditColumnFlag    = "flag"
+	GeneratedEditColumnContact = "contact"
+)
+
+func (m *GeneratedEdit) ListColumnMap() map[string]any {
+	return map[string]any{
+		GeneratedEditColumnId:      &m.Id,
+		GeneratedEditColumnName:    &m.Name,
+		GeneratedEditColumnFlag:    &m.Flag,
+		GeneratedEditColumnContact: &m.Contact,
+	}
+}
+
+func (m *GeneratedEdit) DefaultSortColumns() []string {
+	return []string{GeneratedEditColumnId}
+}
+
+func (m *GeneratedEdit) PKColumnMap() map[string]any {
+	return map[string]any{
+		GeneratedEditColumnId: m.Id,
+	}
+}
+
+func (m *GeneratedEdit) CreateColumnMap() map[string]any {
+	result := make(map[string]any, 4)
+
+	result[GeneratedEditColumnId] = m.Id
+
+	if m.Name != nil {
+		result[GeneratedEditColumnName] = *m.Name
+	}
+
+	if m.Flag != nil {
+		result[GeneratedEditColumnFlag] = *m.Flag
+	}
+
+	if m.Contact != nil {
+		result[GeneratedEditColumnContact] = *m.Contact
+	}
+
+	return result
+}
+
+func (m *GeneratedEdit) UpdateColumnMap() map[string]any {
+	result := make(map[string]any, 3)
+
+	if m.Name != nil {
+		result[GeneratedEditColumnName] = *m.Name
+	}
+
+	if m.Flag != nil {
+		result[GeneratedEditColumnFlag] = *m.Flag
+	}
+
+	if m.Contact != nil {
+		result[GeneratedEditColumnContact] = *m.Contact
+	}
+
+	return result
+}
+
+func (m *GeneratedEdit) ReturningColumnMap() map[string]any {
+	return nil
+}
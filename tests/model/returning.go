@@ -0,0 +1,26 @@
+package model
+
+type UpsertReturning struct {
+	Upsert
+
+	Result Select
+}
+
+func (m *UpsertReturning) ReturningColumnMap() map[string]any {
+	return m.Result.ListColumnMap()
+}
+
+func (m *UpsertReturning) WriteReturningColumnMap() map[string]any {
+	return m.Result.ListColumnMap()
+}
+
+// UpsertReturningPK also inserts the PK, so UpdateOrCreate hits ON CONFLICT (id).
+type UpsertReturningPK struct {
+	UpsertReturning
+}
+
+func (m *UpsertReturningPK) CreateColumnMap() map[string]any {
+	result := m.Upsert.CreateColumnMap()
+	result["id"] = m.PKId
+	return result
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/mechta-market/mobone/v2"
+)
+
+type Stock struct {
+	Id          int
+	Sku         string
+	WarehouseId int
+	Qty         int
+	UpdatedAt   time.Time
+}
+
+func (m *Stock) ListColumnMap() map[string]any {
+	return map[string]any{
+		"id":           &m.Id,
+		"sku":          &m.Sku,
+		"warehouse_id": &m.WarehouseId,
+		"qty":          &m.Qty,
+		"updated_at":   &m.UpdatedAt,
+	}
+}
+
+func (m *Stock) PKColumnMap() map[string]any {
+	return map[string]any{
+		"id": m.Id,
+	}
+}
+
+func (m *Stock) DefaultSortColumns() []string {
+	return []string{"id"}
+}
+
+type StockUpsert struct {
+	Sku         string
+	WarehouseId int
+	Qty         int
+	UpdatedAt   time.Time
+}
+
+func (m *StockUpsert) CreateColumnMap() map[string]any {
+	return map[string]any{
+		"sku":          m.Sku,
+		"warehouse_id": m.WarehouseId,
+		"qty":          m.Qty,
+		"updated_at":   m.UpdatedAt,
+	}
+}
+
+func (m *StockUpsert) UpdateColumnMap() map[string]any {
+	return map[string]any{
+		"qty":        m.Qty,
+		"updated_at": m.UpdatedAt,
+	}
+}
+
+func (m *StockUpsert) ReturningColumnMap() map[string]any {
+	return nil
+}
+
+func (m *StockUpsert) PKColumnMap() map[string]any {
+	return map[string]any{
+		"sku":          m.Sku,
+		"warehouse_id": m.WarehouseId,
+	}
+}
+
+func (m *StockUpsert) OnConflict() mobone.OnConflict {
+	return mobone.OnConflict{
+		Columns:     []string{"sku", "warehouse_id"},
+		UpdateWhere: squirrel.Expr("excluded.updated_at > t.updated_at"),
+	}
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+type Tagged struct {
+	Id        int       `db:"id,pk,readonly,returning"`
+	CreatedAt time.Time `db:"created_at,readonly,returning"`
+	Name      string    `db:"name"`
+	Flag      *bool     `db:"flag,omitempty"`
+	Contact   Contact   `db:"contact,omitempty"`
+
+	Comment string
+}
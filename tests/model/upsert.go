@@ -59,3 +59,14 @@ func (m *Upsert) PKColumnMap() map[string]any {
 		"id": m.PKId,
 	}
 }
+
+// UpsertPK also inserts the PK, so UpdateOrCreate conflicts on it.
+type UpsertPK struct {
+	Upsert
+}
+
+func (m *UpsertPK) CreateColumnMap() map[string]any {
+	result := m.Upsert.CreateColumnMap()
+	result["id"] = m.PKId
+	return result
+}
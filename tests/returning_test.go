@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestReturning(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	name := "Test Model"
+	createModel := &model.UpsertReturning{Upsert: model.Upsert{Name: &name}}
+	err = modelStore.Create(ctx, createModel)
+	require.NoError(t, err)
+	require.Greater(t, createModel.Result.Id, 0)
+	require.Equal(t, name, createModel.Result.Name)
+	id := createModel.Result.Id
+
+	// update
+	newName := "Test Model changed"
+	updatedAt := time.Now().Add(-time.Hour)
+	updateModel := &model.UpsertReturning{Upsert: model.Upsert{PKId: id, Name: &newName, UpdatedAt: &updatedAt}}
+	rowsAffected, err := modelStore.UpdateRows(ctx, updateModel)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rowsAffected)
+	require.Equal(t, id, updateModel.Result.Id)
+	require.Equal(t, newName, updateModel.Result.Name)
+	require.WithinDuration(t, updatedAt, updateModel.Result.UpdatedAt, time.Millisecond)
+
+	// update or create
+	upsertName := "Test Model upserted"
+	flag := true
+	upsertModel := &model.UpsertReturningPK{UpsertReturning: model.UpsertReturning{Upsert: model.Upsert{PKId: id, Name: &upsertName, Flag: &flag}}}
+	result, err := modelStore.UpdateOrCreateWithResult(ctx, upsertModel)
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertUpdated, result)
+	require.Equal(t, id, upsertModel.Result.Id)
+	require.Equal(t, upsertName, upsertModel.Result.Name)
+	require.True(t, upsertModel.Result.Flag)
+
+	// create if not exist: existing row returns nothing
+	existingModel := &model.UpsertReturning{Upsert: model.Upsert{PKId: id, Name: &name}}
+	err = modelStore.CreateIfNotExist(ctx, existingModel)
+	require.NoError(t, err)
+	require.Equal(t, 0, existingModel.Result.Id)
+
+	newModel := &model.UpsertReturning{Upsert: model.Upsert{PKId: id + 100, Name: &name}}
+	err = modelStore.CreateIfNotExist(ctx, newModel)
+	require.NoError(t, err)
+	require.Equal(t, id+100, newModel.Result.Id)
+	require.Equal(t, name, newModel.Result.Name)
+
+	// delete
+	deleteModel := &model.UpsertReturning{Upsert: model.Upsert{PKId: id}}
+	rowsAffected, err = modelStore.DeleteRows(ctx, deleteModel)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rowsAffected)
+	require.Equal(t, upsertName, deleteModel.Result.Name)
+
+	rowsAffected, err = modelStore.DeleteRows(ctx, &model.UpsertReturning{Upsert: model.Upsert{PKId: id}})
+	require.NoError(t, err)
+	require.EqualValues(t, 0, rowsAffected)
+
+	// without WithReturningI only Create uses RETURNING
+	plainModel := &model.Upsert{Name: &name}
+	err = modelStore.Create(ctx, plainModel)
+	require.NoError(t, err)
+	createdId := plainModel.PKId
+	require.Greater(t, createdId, 0)
+
+	rowsAffected, err = modelStore.UpdateRows(ctx, &model.Upsert{PKId: createdId, Name: &newName})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rowsAffected)
+}
+
+func TestUpsertResult(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	name := "Test Model"
+
+	result, err := modelStore.UpdateOrCreateWithResult(ctx, &model.UpsertPK{Upsert: model.Upsert{PKId: 1, Name: &name}})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertInserted, result)
+
+	newName := "Test Model changed"
+	result, err = modelStore.UpdateOrCreateWithResult(ctx, &model.UpsertPK{Upsert: model.Upsert{PKId: 1, Name: &newName}})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertUpdated, result)
+
+	result, err = modelStore.CreateIfNotExistWithResult(ctx, &model.Upsert{PKId: 1, Name: &name})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertSkipped, result)
+
+	result, err = modelStore.CreateIfNotExistWithResult(ctx, &model.Upsert{PKId: 2, Name: &name})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertInserted, result)
+
+	dbItem := &model.Select{Id: 1}
+	found, err := modelStore.Get(ctx, dbItem)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, newName, dbItem.Name)
+}
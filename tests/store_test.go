@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestStore(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+", "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	stockStore := mobone.NewStore[model.Stock](&mobone.ModelStore{
+		Con:          dbCon.pool,
+		QB:           queryBuilder,
+		TableName:    stockTableName,
+		CursorSecret: []byte("secret"),
+	})
+
+	// other models are written through the embedded ModelStore
+	for i := 1; i <= 5; i++ {
+		err = stockStore.ModelStore.Create(ctx, &model.StockUpsert{Sku: "sku-1", WarehouseId: i, Qty: i})
+		require.NoError(t, err)
+	}
+	err = stockStore.Delete(ctx, &model.Stock{Id: 5})
+	require.NoError(t, err)
+
+	// Stock does not implement CreateModelI
+	err = stockStore.Create(ctx, &model.Stock{Sku: "sku-2"})
+	require.ErrorContains(t, err, "does not implement CreateModelI")
+
+	items, totalCount, err := stockStore.List(ctx, mobone.ListParams{
+		ConditionExpressions: map[string][]any{"qty > ?": {1}},
+		Sort:                 []string{"id desc"},
+		WithTotalCount:       true,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, totalCount)
+	require.Len(t, items, 3)
+	require.Equal(t, 4, items[0].Id)
+	require.Equal(t, "sku-1", items[0].Sku)
+	require.Equal(t, 2, items[2].Qty)
+
+	items, result, err := stockStore.ListPage(ctx, mobone.ListParams{PageSize: 3})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, stockIds(pointers(items)))
+	require.NotEmpty(t, result.NextCursor)
+
+	item, found, err := stockStore.Get(ctx, map[string]any{"id": int64(3)})
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 3, item.WarehouseId)
+
+	_, found, err = stockStore.Get(ctx, map[string]any{"id": 5})
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, _, err = stockStore.Get(ctx, nil)
+	require.ErrorIs(t, err, mobone.ErrEmptyConditions)
+
+	_, _, err = stockStore.Get(ctx, map[string]any{"sku": "sku-1"})
+	require.Error(t, err)
+
+	items, missingKeys, err := stockStore.GetMany(ctx, mobone.GetManyParams{
+		Keys:      []map[string]any{{"id": 2}, {"id": 5}, {"id": 1}},
+		KeepOrder: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 1}, stockIds(pointers(items)))
+	require.Equal(t, []map[string]any{{"id": 5}}, missingKeys)
+
+	var ids []int
+	for item, err := range stockStore.Iter(ctx, mobone.ListParams{Sort: []string{"id desc"}}) {
+		require.NoError(t, err)
+		ids = append(ids, item.Id)
+	}
+	require.Equal(t, []int{4, 3, 2, 1}, ids)
+
+	ids = nil
+	err = stockStore.ForEachChunk(ctx, mobone.ListParams{}, 3, func(ctx context.Context, items []model.Stock) error {
+		ids = append(ids, len(items))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 1}, ids)
+
+	// typed mutators
+	generatedStore := mobone.NewStore[model.Generated](&mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	})
+
+	generatedItem := &model.Generated{Name: "Name 1"}
+	err = generatedStore.Create(ctx, generatedItem)
+	require.NoError(t, err)
+	require.Equal(t, 1, generatedItem.Id)
+
+	generatedItem.Name = "Name 1 changed"
+	err = generatedStore.Update(ctx, generatedItem)
+	require.NoError(t, err)
+
+	err = generatedStore.UpdateOrCreate(ctx, &model.Generated{Name: "Name 2"})
+	require.NoError(t, err)
+
+	err = generatedStore.Delete(ctx, &model.Generated{Id: 2})
+	require.NoError(t, err)
+
+	generatedItems, _, err := generatedStore.List(ctx, mobone.ListParams{})
+	require.NoError(t, err)
+	require.Len(t, generatedItems, 1)
+	require.Equal(t, "Name 1 changed", generatedItems[0].Name)
+}
+
+func pointers[T any](items []T) []*T {
+	result := make([]*T, 0, len(items))
+	for i := range items {
+		result = append(result, &items[i])
+	}
+	return result
+}
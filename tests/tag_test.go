@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestTagModel(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: tableName,
+	}
+
+	// readonly columns are not written, returning ones are scanned back
+	flag := true
+	item := &model.Tagged{Name: "Name 1", Flag: &flag, Contact: model.Contact{Phone: "123"}}
+	err = modelStore.Create(ctx, mobone.TagModel(item))
+	require.NoError(t, err)
+	require.Equal(t, 1, item.Id)
+	require.False(t, item.CreatedAt.IsZero())
+
+	err = modelStore.Create(ctx, mobone.TagModel(&model.Tagged{Name: "Name 2"}))
+	require.NoError(t, err)
+
+	// omitempty skips the nil pointer, pk is only used in WHERE
+	err = modelStore.Update(ctx, mobone.TagModel(&model.Tagged{Id: 1, Name: "Name 1 changed"}))
+	require.NoError(t, err)
+
+	dbItem := &model.Tagged{Id: 1}
+	found, err := modelStore.Get(ctx, mobone.TagModel(dbItem))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "Name 1 changed", dbItem.Name)
+	require.NotNil(t, dbItem.Flag)
+	require.True(t, *dbItem.Flag)
+	require.Equal(t, "123", dbItem.Contact.Phone)
+	require.Equal(t, item.CreatedAt.UnixMicro(), dbItem.CreatedAt.UnixMicro())
+
+	// default sort by pk
+	var items []*model.Tagged
+	_, err = modelStore.List(ctx, mobone.ListParams{}, func(add bool) mobone.ListModelI {
+		it := &model.Tagged{}
+		if add {
+			items = append(items, it)
+		}
+		return mobone.TagModel(it)
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, 1, items[0].Id)
+	require.Equal(t, "Name 2", items[1].Name)
+	// omitempty skipped the nil flag on Create, so the column default was used
+	require.NotNil(t, items[1].Flag)
+	require.False(t, *items[1].Flag)
+
+	err = modelStore.Delete(ctx, mobone.TagModel(&model.Tagged{Id: 2}))
+	require.NoError(t, err)
+
+	found, err = modelStore.Get(ctx, mobone.TagModel(&model.Tagged{Id: 2}))
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// the sort columns are a copy of the shared metadata
+	sortColumns := mobone.TagModel(&model.Tagged{}).DefaultSortColumns()
+	sortColumns[0] = "name"
+	require.Equal(t, []string{"id"}, mobone.TagModel(&model.Tagged{}).DefaultSortColumns())
+
+	// invalid input
+	require.Panics(t, func() { mobone.TagModel(model.Tagged{}) })
+	require.Panics(t, func() { mobone.TagModel(&struct{ Name string }{}) })
+	require.Panics(t, func() {
+		mobone.TagModel(&struct {
+			Name string `db:"name,unique"`
+		}{})
+	})
+	require.Panics(t, func() {
+		mobone.TagModel(&struct {
+			A string `db:"name"`
+			B string `db:"name"`
+		}{})
+	})
+}
@@ -0,0 +1,435 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestNestedTransaction(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	modelStore := mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+
+	outerName := "Outer"
+	innerName := "Inner"
+	outerModel := &model.Upsert{Name: &outerName}
+	innerModel := &model.Upsert{Name: &innerName}
+
+	txFnErr := txM.TxFn(bgCtx, func(ctx context.Context) error {
+		err := modelStore.Create(ctx, outerModel)
+		if err != nil {
+			return err
+		}
+
+		// successful nested call must not commit the outer transaction
+		err = txM.TxFn(ctx, func(ctx context.Context) error {
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// failed nested call must roll back only to its savepoint
+		nestedErr := txM.TxFn(ctx, func(ctx context.Context) error {
+			err := modelStore.Create(ctx, innerModel)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("test error")
+		})
+		require.ErrorContains(t, nestedErr, "test error")
+
+		return nil
+	})
+	require.NoError(t, txFnErr)
+
+	dbItem := &model.Select{Id: outerModel.PKId}
+	found, err := modelStore.Get(bgCtx, dbItem)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, outerName, dbItem.Name)
+
+	dbItem = &model.Select{Id: innerModel.PKId}
+	found, err = modelStore.Get(bgCtx, dbItem)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestTransactionOptions(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	modelStore := mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+
+	name := "Test Model"
+
+	readOnlyOpts := pgx.TxOptions{
+		IsoLevel:       pgx.RepeatableRead,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.Deferrable,
+	}
+
+	txFnErr := txM.TxFnWithOptions(bgCtx, readOnlyOpts, func(ctx context.Context) error {
+		var isoLevel string
+		err := modelStore.GetConnection(ctx).QueryRow(ctx, "show transaction_isolation").Scan(&isoLevel)
+		if err != nil {
+			return err
+		}
+		require.Equal(t, "repeatable read", isoLevel)
+
+		return modelStore.Create(ctx, &model.Upsert{Name: &name})
+	})
+	require.Error(t, txFnErr)
+	require.ErrorContains(t, txFnErr, "read-only transaction")
+
+	txFnErr = txM.TxFnWithOptions(bgCtx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(ctx context.Context) error {
+		// empty options inherit the outer ones
+		err := txM.TxFn(ctx, func(ctx context.Context) error {
+			return modelStore.Create(ctx, &model.Upsert{Name: &name})
+		})
+		if err != nil {
+			return err
+		}
+
+		return txM.TxFnWithOptions(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted}, func(ctx context.Context) error {
+			return nil
+		})
+	})
+	require.Error(t, txFnErr)
+	require.ErrorContains(t, txFnErr, "incompatible")
+
+	// an empty outer isolation level is read committed
+	txFnErr = txM.TxFn(bgCtx, func(ctx context.Context) error {
+		return txM.TxFnWithOptions(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted, AccessMode: pgx.ReadWrite}, func(ctx context.Context) error {
+			return nil
+		})
+	})
+	require.NoError(t, txFnErr)
+
+	listCount, err := modelStore.List(bgCtx, mobone.ListParams{
+		OnlyCount: true,
+	}, func(add bool) mobone.ListModelI {
+		return &model.Select{}
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, int(listCount))
+}
+
+func TestTransactionRetry(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	retryAttempts := make([]int, 0, 2)
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+	txM.RetryPolicy = &mobone.RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  10 * time.Millisecond,
+		OnRetry: func(ctx context.Context, attempt int, err error) {
+			retryAttempts = append(retryAttempts, attempt)
+		},
+	}
+
+	modelStore := mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+
+	name := "Test Model"
+
+	calls := 0
+	txFnErr := txM.TxFn(bgCtx, func(ctx context.Context) error {
+		calls++
+
+		err := modelStore.Create(ctx, &model.Upsert{Name: &name})
+		if err != nil {
+			return err
+		}
+
+		if calls < 3 {
+			return &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+		}
+
+		return nil
+	})
+	require.NoError(t, txFnErr)
+	require.Equal(t, 3, calls)
+	require.Equal(t, []int{1, 2}, retryAttempts)
+
+	listCount, err := modelStore.List(bgCtx, mobone.ListParams{
+		OnlyCount: true,
+	}, func(add bool) mobone.ListModelI {
+		return &model.Select{}
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, int(listCount))
+
+	// not retryable errors are returned immediately
+	calls = 0
+	txFnErr = txM.TxFn(bgCtx, func(ctx context.Context) error {
+		calls++
+		return fmt.Errorf("test error")
+	})
+	require.ErrorContains(t, txFnErr, "test error")
+	require.Equal(t, 1, calls)
+
+	// attempts are limited
+	calls = 0
+	txFnErr = txM.TxFn(bgCtx, func(ctx context.Context) error {
+		calls++
+		return &pgconn.PgError{Code: "40P01", Message: "deadlock detected"}
+	})
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, txFnErr, &pgErr)
+	require.Equal(t, "40P01", pgErr.Code)
+	require.Equal(t, 3, calls)
+}
+
+func TestTransactionCallbacks(t *testing.T) {
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	events := make([]string, 0, 8)
+	add := func(event string) func() {
+		return func() { events = append(events, event) }
+	}
+
+	// outside a transaction
+	mobone.OnCommit(bgCtx, add("no-tx commit"))
+	mobone.OnRollback(bgCtx, add("no-tx rollback"))
+	require.Equal(t, []string{"no-tx commit"}, events)
+
+	// commit
+	events = events[:0]
+	txFnErr := txM.TxFn(bgCtx, func(ctx context.Context) error {
+		mobone.OnCommit(ctx, add("commit 1"))
+		mobone.OnRollback(ctx, add("rollback 1"))
+
+		err := txM.TxFn(ctx, func(ctx context.Context) error {
+			mobone.OnCommit(ctx, add("nested commit"))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		_ = txM.TxFn(ctx, func(ctx context.Context) error {
+			mobone.OnCommit(ctx, add("failed nested commit"))
+			mobone.OnRollback(ctx, add("failed nested rollback"))
+			return fmt.Errorf("test error")
+		})
+
+		mobone.OnCommit(ctx, add("commit 2"))
+
+		require.Equal(t, []string{"failed nested rollback"}, events)
+
+		return nil
+	})
+	require.NoError(t, txFnErr)
+	require.Equal(t, []string{"failed nested rollback", "commit 1", "nested commit", "commit 2"}, events)
+
+	// rollback
+	events = events[:0]
+	txFnErr = txM.TxFn(bgCtx, func(ctx context.Context) error {
+		mobone.OnCommit(ctx, add("commit"))
+		mobone.OnRollback(ctx, add("rollback 1"))
+
+		err := txM.TxFn(ctx, func(ctx context.Context) error {
+			mobone.OnRollback(ctx, add("nested rollback"))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		mobone.OnRollback(ctx, add("rollback 2"))
+
+		return fmt.Errorf("test error")
+	})
+	require.ErrorContains(t, txFnErr, "test error")
+	require.Equal(t, []string{"rollback 1", "nested rollback", "rollback 2"}, events)
+}
+
+func TestTransactionPanicAndCancel(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	modelStore := mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+
+	name := "Test Model"
+
+	// panic is rolled back and re-panicked
+	rolledBack := false
+	require.PanicsWithValue(t, "test panic", func() {
+		_ = txM.TxFn(bgCtx, func(ctx context.Context) error {
+			mobone.OnRollback(ctx, func() { rolledBack = true })
+
+			err := modelStore.Create(ctx, &model.Upsert{Name: &name})
+			if err != nil {
+				return err
+			}
+
+			panic("test panic")
+		})
+	})
+	require.True(t, rolledBack)
+
+	// panic is returned as error
+	txM.PanicAsError = true
+	txFnErr := txM.TxFn(bgCtx, func(ctx context.Context) error {
+		err := modelStore.Create(ctx, &model.Upsert{Name: &name})
+		if err != nil {
+			return err
+		}
+
+		panic("test panic")
+	})
+	var panicErr *mobone.PanicError
+	require.ErrorAs(t, txFnErr, &panicErr)
+	require.Equal(t, "test panic", panicErr.Value)
+
+	// rollback still reaches the server when the caller's context is cancelled
+	cancelCtx, cancel := context.WithCancel(bgCtx)
+	txFnErr = txM.TxFn(cancelCtx, func(ctx context.Context) error {
+		err := modelStore.Create(ctx, &model.Upsert{Name: &name})
+		if err != nil {
+			return err
+		}
+
+		cancel()
+
+		return ctx.Err()
+	})
+	require.ErrorIs(t, txFnErr, context.Canceled)
+
+	listCount, err := modelStore.List(bgCtx, mobone.ListParams{
+		OnlyCount: true,
+	}, func(add bool) mobone.ListModelI {
+		return &model.Select{}
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, int(listCount))
+
+	requireNoActiveTransactions(t)
+}
+
+func requireNoActiveTransactions(t *testing.T) {
+	var noActive bool
+	err := dbCon.pool.QueryRow(context.Background(), `
+        select count(*) = 0 as no_active_tx
+        from pg_stat_activity
+        where state in ('idle in transaction', 'idle in transaction (aborted)')
+          and pid <> pg_backend_pid();
+    `).Scan(&noActive)
+	require.NoError(t, err)
+	require.True(t, noActive)
+}
+
+func TestMultipleTransactionManagers(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+tableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	otherCon, err := NewCon(dbCon.pool.Config().ConnConfig.Database)
+	require.NoError(t, err)
+	defer otherCon.Close()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+	otherTxM := mobone.NewTransactionManager(otherCon.pool)
+
+	modelStore := mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+
+	otherModelStore := mobone.ModelStore{
+		Con:                otherCon.pool,
+		TransactionManager: otherTxM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+
+	name := "Test Model"
+	createModel := &model.Upsert{Name: &name}
+
+	txFnErr := txM.TxFn(bgCtx, func(ctx context.Context) error {
+		err := modelStore.Create(ctx, createModel)
+		if err != nil {
+			return err
+		}
+
+		// the other manager must not pick up this transaction
+		return otherTxM.TxFn(ctx, func(ctx context.Context) error {
+			found, err := otherModelStore.Get(ctx, &model.Select{Id: createModel.PKId})
+			if err != nil {
+				return err
+			}
+			require.False(t, found)
+
+			found, err = modelStore.Get(ctx, &model.Select{Id: createModel.PKId})
+			if err != nil {
+				return err
+			}
+			require.True(t, found)
+
+			return nil
+		})
+	})
+	require.NoError(t, txFnErr)
+
+	misconfiguredStore := mobone.ModelStore{
+		Con:                otherCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          tableName,
+	}
+	_, err = misconfiguredStore.Get(bgCtx, &model.Select{Id: createModel.PKId})
+	require.ErrorIs(t, err, mobone.ErrTransactionManagerMismatch)
+	require.Panics(t, func() { misconfiguredStore.GetConnection(bgCtx) })
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestUpsertOnConflict(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: stockTableName,
+	}
+
+	now := time.Now()
+
+	result, err := modelStore.UpdateOrCreateWithResult(ctx, &model.StockUpsert{Sku: "sku-1", WarehouseId: 1, Qty: 10, UpdatedAt: now})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertInserted, result)
+
+	// newer data updates the row
+	result, err = modelStore.UpdateOrCreateWithResult(ctx, &model.StockUpsert{Sku: "sku-1", WarehouseId: 1, Qty: 7, UpdatedAt: now.Add(time.Minute)})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertUpdated, result)
+
+	// stale data is skipped by the DO UPDATE condition
+	result, err = modelStore.UpdateOrCreateWithResult(ctx, &model.StockUpsert{Sku: "sku-1", WarehouseId: 1, Qty: 3, UpdatedAt: now})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertSkipped, result)
+
+	result, err = modelStore.UpdateOrCreateWithResult(ctx, &model.StockUpsert{Sku: "sku-1", WarehouseId: 2, Qty: 5, UpdatedAt: now})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertInserted, result)
+
+	result, err = modelStore.CreateIfNotExistWithResult(ctx, &model.StockUpsert{Sku: "sku-1", WarehouseId: 2, Qty: 1, UpdatedAt: now})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertSkipped, result)
+
+	items := make([]*model.Stock, 0, 2)
+	_, err = modelStore.List(ctx, mobone.ListParams{}, func(add bool) mobone.ListModelI {
+		x := &model.Stock{}
+		if add {
+			items = append(items, x)
+		}
+		return x
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, 7, items[0].Qty)
+	require.Equal(t, 5, items[1].Qty)
+}
+
+func TestCreateIfNotExistNaturalKey(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: stockTableName,
+	}
+
+	// the serial id is left to the database, the conflict is on the natural key
+	for _, m := range []*stockIdUpsert{
+		{StockUpsert: model.StockUpsert{Sku: "sku-1", WarehouseId: 1, Qty: 1}},
+		{StockUpsert: model.StockUpsert{Sku: "sku-1", WarehouseId: 2, Qty: 2}},
+	} {
+		result, err := modelStore.CreateIfNotExistWithResult(ctx, m)
+		require.NoError(t, err)
+		require.Equal(t, mobone.UpsertInserted, result)
+	}
+
+	result, err := modelStore.CreateIfNotExistWithResult(ctx, &stockIdUpsert{StockUpsert: model.StockUpsert{Sku: "sku-1", WarehouseId: 2, Qty: 3}})
+	require.NoError(t, err)
+	require.Equal(t, mobone.UpsertSkipped, result)
+}
+
+// stockIdUpsert is a stock upsert identified by its serial id
+type stockIdUpsert struct {
+	model.StockUpsert
+	Id int
+}
+
+func (m *stockIdUpsert) PKColumnMap() map[string]any {
+	return map[string]any{
+		"id": m.Id,
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestWalkPKRange(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	bgCtx := context.Background()
+
+	txM := mobone.NewTransactionManager(dbCon.pool)
+
+	stockStore := &mobone.ModelStore{
+		Con:                dbCon.pool,
+		TransactionManager: txM,
+		QB:                 queryBuilder,
+		TableName:          stockTableName,
+	}
+
+	for i := 1; i <= 10; i++ {
+		err = stockStore.Create(bgCtx, &model.StockUpsert{Sku: "sku-1", WarehouseId: i, Qty: i})
+		require.NoError(t, err)
+	}
+
+	newItem := func() mobone.ListModelI {
+		return &model.Stock{}
+	}
+
+	checkpoints := mobone.NewMemoryCheckpointStore()
+	errStop := errors.New("stop")
+
+	params := mobone.WalkParams{
+		Name:                 "stock-backfill",
+		ChunkSize:            3,
+		ConditionExpressions: map[string][]any{"qty <> ?": {5}},
+		Checkpoints:          checkpoints,
+		Throttle:             time.Millisecond,
+		TransactionManager:   txM,
+	}
+
+	// the second chunk fails after an update, which is rolled back with its checkpoint
+	var chunks [][]int
+	err = stockStore.WalkPKRange(bgCtx, params, newItem, func(ctx context.Context, items []mobone.ListModelI) error {
+		var ids []int
+		for _, item := range items {
+			ids = append(ids, item.(*model.Stock).Id)
+			_, err := stockStore.UpdateWhere(ctx, map[string]any{"qty": 100}, mobone.WhereParams{
+				Conditions: map[string]any{"id": item.(*model.Stock).Id},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		chunks = append(chunks, ids)
+		if len(chunks) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, [][]int{{1, 2, 3}, {4, 6, 7}}, chunks)
+
+	key, found, err := checkpoints.Load(bgCtx, params.Name)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "3", key)
+
+	// resume from the checkpoint
+	chunks = nil
+	err = stockStore.WalkPKRange(bgCtx, params, newItem, func(ctx context.Context, items []mobone.ListModelI) error {
+		var ids []int
+		for _, item := range items {
+			ids = append(ids, item.(*model.Stock).Id)
+			require.NotEqual(t, 100, item.(*model.Stock).Qty)
+		}
+		chunks = append(chunks, ids)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{4, 6, 7}, {8, 9, 10}}, chunks)
+
+	key, _, err = checkpoints.Load(bgCtx, params.Name)
+	require.NoError(t, err)
+	require.Equal(t, "10", key)
+	requireNoActiveTransactions(t)
+
+	// without checkpoints and transactions, explicit pk column
+	chunks = nil
+	err = stockStore.WalkPKRange(bgCtx, mobone.WalkParams{PKColumn: "id", ChunkSize: 5}, newItem, func(ctx context.Context, items []mobone.ListModelI) error {
+		var ids []int
+		for _, item := range items {
+			ids = append(ids, item.(*model.Stock).Id)
+		}
+		chunks = append(chunks, ids)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{1, 2, 3, 4, 5}, {6, 7, 8, 9, 10}}, chunks)
+}
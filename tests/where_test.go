@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mechta-market/mobone/v2"
+	"github.com/mechta-market/mobone/v2/tests/model"
+)
+
+func TestUpdateDeleteWhere(t *testing.T) {
+	_, err := dbCon.pool.Exec(context.Background(), "truncate table "+stockTableName+" RESTART IDENTITY")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	modelStore := mobone.ModelStore{
+		Con:       dbCon.pool,
+		QB:        queryBuilder,
+		TableName: stockTableName,
+	}
+
+	for _, m := range []*model.StockUpsert{
+		{Sku: "sku-1", WarehouseId: 1, Qty: 1},
+		{Sku: "sku-2", WarehouseId: 1, Qty: 2},
+		{Sku: "sku-3", WarehouseId: 1, Qty: 3},
+		{Sku: "sku-1", WarehouseId: 2, Qty: 4},
+	} {
+		err = modelStore.Create(ctx, m)
+		require.NoError(t, err)
+	}
+
+	// empty conditions are refused
+	_, err = modelStore.UpdateWhere(ctx, map[string]any{"qty": 0}, mobone.WhereParams{})
+	require.ErrorIs(t, err, mobone.ErrEmptyConditions)
+	_, err = modelStore.DeleteWhere(ctx, mobone.WhereParams{})
+	require.ErrorIs(t, err, mobone.ErrEmptyConditions)
+
+	rowsAffected, err := modelStore.UpdateWhere(ctx, map[string]any{"qty": 0}, mobone.WhereParams{
+		Conditions: map[string]any{"warehouse_id": 1},
+		ConditionExpressions: map[string][]any{
+			"qty >= ?": {2},
+		},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, rowsAffected)
+
+	returned := make([]*model.Stock, 0, 2)
+	rowsAffected, err = modelStore.DeleteWhere(ctx, mobone.WhereParams{
+		Conditions: map[string]any{"qty": 0},
+		Returning: func(add bool) mobone.ListModelI {
+			x := &model.Stock{}
+			if add {
+				returned = append(returned, x)
+			}
+			return x
+		},
+		ReturningColumns: []string{"sku", "warehouse_id"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, rowsAffected)
+	require.Len(t, returned, 2)
+	require.ElementsMatch(t, []string{"sku-2", "sku-3"}, []string{returned[0].Sku, returned[1].Sku})
+
+	rowsAffected, err = modelStore.UpdateWhere(ctx, map[string]any{"qty": 10}, mobone.WhereParams{AllowEmpty: true})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, rowsAffected)
+
+	rowsAffected, err = modelStore.DeleteWhere(ctx, mobone.WhereParams{AllowEmpty: true})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, rowsAffected)
+}
@@ -3,17 +3,60 @@ package mobone
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type transactionCtxKeyT int8
+// transactionCtxKeyT scopes the transaction stored in a context to its manager,
+// so managers of different databases do not see each other's transactions.
+type transactionCtxKeyT struct {
+	tm *TransactionManager
+}
+
+// currentTransactionCtxKey holds the innermost transaction of any manager, for OnCommit/OnRollback.
+type currentTransactionCtxKeyT struct{}
 
-const transactionCtxKey = transactionCtxKeyT(1)
+const defaultRollbackTimeout = 5 * time.Second
 
 type TransactionManager struct {
 	con *pgxpool.Pool
+
+	// RetryPolicy enables re-running the outermost TxFn on serialization failures and deadlocks
+	RetryPolicy *RetryPolicy
+
+	// RollbackTimeout limits the rollback, which runs detached from the caller's context cancellation
+	RollbackTimeout time.Duration
+
+	// PanicAsError makes TxFn return a *PanicError instead of re-panicking after the rollback
+	PanicAsError bool
+}
+
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("transaction function panic: %v", e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+type txState struct {
+	tx     pgx.Tx
+	opts   pgx.TxOptions
+	parent *txState
+
+	mu         sync.Mutex
+	onCommit   []func()
+	onRollback []func()
 }
 
 func NewTransactionManager(con *pgxpool.Pool) *TransactionManager {
@@ -22,30 +65,53 @@ func NewTransactionManager(con *pgxpool.Pool) *TransactionManager {
 	}
 }
 
-func (s *TransactionManager) getContextTransaction(ctx context.Context) pgx.Tx {
-	contextV := ctx.Value(transactionCtxKey)
-	if contextV == nil {
-		return nil
+func (s *TransactionManager) getContextTxState(ctx context.Context) *txState {
+	if state, ok := ctx.Value(transactionCtxKeyT{tm: s}).(*txState); ok {
+		return state
 	}
 
-	if tx, ok := contextV.(pgx.Tx); ok {
-		return tx
+	return nil
+}
+
+func (s *TransactionManager) contextWithTxState(ctx context.Context, state *txState) context.Context {
+	ctx = context.WithValue(ctx, transactionCtxKeyT{tm: s}, state)
+	return context.WithValue(ctx, currentTransactionCtxKeyT{}, state)
+}
+
+func (s *TransactionManager) getContextTransaction(ctx context.Context) pgx.Tx {
+	if state := s.getContextTxState(ctx); state != nil {
+		return state.tx
 	}
 
 	return nil
 }
 
-func (s *TransactionManager) contextWithTransaction(ctx context.Context) (context.Context, pgx.Tx, error) {
-	if tx := s.getContextTransaction(ctx); tx != nil {
-		return ctx, tx, nil
+func (s *TransactionManager) contextWithTransaction(ctx context.Context, opts pgx.TxOptions) (context.Context, *txState, error) {
+	// nested call: pgx opens a savepoint on Begin, Commit releases it and Rollback rolls back to it
+	if parentState := s.getContextTxState(ctx); parentState != nil {
+		err := checkNestedTxOptions(parentState.opts, opts)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		tx, err := parentState.tx.Begin(ctx)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("unable to create savepoint: %w", err)
+		}
+
+		state := &txState{tx: tx, opts: parentState.opts, parent: parentState}
+
+		return s.contextWithTxState(ctx, state), state, nil
 	}
 
-	tx, err := s.con.Begin(ctx)
+	tx, err := s.con.BeginTx(ctx, opts)
 	if err != nil {
 		return ctx, nil, fmt.Errorf("unable to begin transaction: %w", err)
 	}
 
-	return context.WithValue(ctx, transactionCtxKey, tx), tx, nil
+	state := &txState{tx: tx, opts: opts}
+
+	return s.contextWithTxState(ctx, state), state, nil
 }
 
 func (s *TransactionManager) GetConnection(ctx context.Context) ConnectionI {
@@ -57,17 +123,59 @@ func (s *TransactionManager) GetConnection(ctx context.Context) ConnectionI {
 }
 
 func (s *TransactionManager) TxFn(ctx context.Context, f func(context.Context) error) error {
+	return s.TxFnWithOptions(ctx, pgx.TxOptions{}, f)
+}
+
+func (s *TransactionManager) TxFnWithOptions(ctx context.Context, opts pgx.TxOptions, f func(context.Context) error) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	ctxWithTx, tx, err := s.contextWithTransaction(ctx)
+	// only the outermost call can retry: a nested one runs inside an already aborted transaction
+	if s.RetryPolicy == nil || s.getContextTxState(ctx) != nil {
+		return s.txFnAttempt(ctx, opts, f)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.txFnAttempt(ctx, opts, f)
+		if err == nil || attempt >= s.RetryPolicy.MaxAttempts || !isRetryableTxError(err) {
+			return err
+		}
+
+		if s.RetryPolicy.OnRetry != nil {
+			s.RetryPolicy.OnRetry(ctx, attempt, err)
+		}
+
+		if sleepErr := sleepContext(ctx, s.RetryPolicy.backoff(attempt)); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+func (s *TransactionManager) txFnAttempt(ctx context.Context, opts pgx.TxOptions, f func(context.Context) error) (err error) {
+	ctxWithTx, state, err := s.contextWithTransaction(ctx, opts)
 	if err != nil {
 		return err
 	}
 
 	// defer rollback
-	defer func() { _ = tx.Rollback(ctx) }()
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+
+		panicV := recover()
+
+		s.rollback(ctx, state)
+
+		if panicV != nil {
+			if !s.PanicAsError {
+				panic(panicV)
+			}
+			err = &PanicError{Value: panicV, Stack: debug.Stack()}
+		}
+	}()
 
 	// run transaction function
 	err = f(ctxWithTx)
@@ -76,10 +184,143 @@ func (s *TransactionManager) TxFn(ctx context.Context, f func(context.Context) e
 	}
 
 	// commit
-	err = tx.Commit(ctx)
+	err = state.tx.Commit(ctx)
 	if err != nil {
 		return fmt.Errorf("transaction commit: %w", err)
 	}
+	committed = true
+	state.committed()
+
+	return nil
+}
+
+// rollback must reach the server even if ctx is already cancelled,
+// otherwise the connection goes back to the pool idle in transaction.
+func (s *TransactionManager) rollback(ctx context.Context, state *txState) {
+	rollbackDetached(ctx, state.tx, s.RollbackTimeout)
+	state.rolledBack()
+}
+
+// rollbackDetached rolls tx back with ctx values but without its cancellation,
+// limited by timeout, or by defaultRollbackTimeout when it is not positive.
+func rollbackDetached(ctx context.Context, tx pgx.Tx, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultRollbackTimeout
+	}
+
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	_ = tx.Rollback(rollbackCtx)
+}
+
+// checkNestedTxOptions reports an error when a nested call asks for options
+// the already running transaction cannot provide. Empty fields inherit the outer ones.
+func checkNestedTxOptions(outer, nested pgx.TxOptions) error {
+	if outer.IsoLevel == "" {
+		outer.IsoLevel = pgx.ReadCommitted
+	}
+	if outer.AccessMode == "" {
+		outer.AccessMode = pgx.ReadWrite
+	}
+	if outer.DeferrableMode == "" {
+		outer.DeferrableMode = pgx.NotDeferrable
+	}
+
+	if nested.IsoLevel != "" && nested.IsoLevel != outer.IsoLevel {
+		return fmt.Errorf("nested transaction isolation level %q is incompatible with %q", nested.IsoLevel, outer.IsoLevel)
+	}
+	if nested.AccessMode != "" && nested.AccessMode != outer.AccessMode {
+		return fmt.Errorf("nested transaction access mode %q is incompatible with %q", nested.AccessMode, outer.AccessMode)
+	}
+	if nested.DeferrableMode != "" && nested.DeferrableMode != outer.DeferrableMode {
+		return fmt.Errorf("nested transaction deferrable mode %q is incompatible with %q", nested.DeferrableMode, outer.DeferrableMode)
+	}
+
+	return nil
+}
+
+// OnCommit registers fn to be called after the outermost transaction in ctx commits.
+// Outside a transaction fn is called immediately.
+// With several managers in ctx the innermost transaction is used, see TransactionManager.OnCommit.
+func OnCommit(ctx context.Context, fn func()) {
+	contextTxState(ctx).addOnCommit(fn)
+}
+
+// OnRollback registers fn to be called after the transaction in ctx is rolled back.
+// For a nested call it fires as soon as its savepoint is rolled back.
+// Outside a transaction fn is never called.
+func OnRollback(ctx context.Context, fn func()) {
+	contextTxState(ctx).addOnRollback(fn)
+}
+
+// OnCommit is like the package level OnCommit, but uses the transaction of this manager.
+func (s *TransactionManager) OnCommit(ctx context.Context, fn func()) {
+	s.getContextTxState(ctx).addOnCommit(fn)
+}
+
+// OnRollback is like the package level OnRollback, but uses the transaction of this manager.
+func (s *TransactionManager) OnRollback(ctx context.Context, fn func()) {
+	s.getContextTxState(ctx).addOnRollback(fn)
+}
+
+func contextTxState(ctx context.Context) *txState {
+	if state, ok := ctx.Value(currentTransactionCtxKeyT{}).(*txState); ok {
+		return state
+	}
 
 	return nil
 }
+
+func (s *txState) addOnCommit(fn func()) {
+	if s == nil {
+		fn()
+		return
+	}
+
+	s.mu.Lock()
+	s.onCommit = append(s.onCommit, fn)
+	s.mu.Unlock()
+}
+
+func (s *txState) addOnRollback(fn func()) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.onRollback = append(s.onRollback, fn)
+	s.mu.Unlock()
+}
+
+// committed hands the callbacks of a released savepoint over to its parent,
+// so they fire together with the outermost transaction.
+func (s *txState) committed() {
+	s.mu.Lock()
+	onCommit, onRollback := s.onCommit, s.onRollback
+	s.onCommit, s.onRollback = nil, nil
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.mu.Lock()
+		s.parent.onCommit = append(s.parent.onCommit, onCommit...)
+		s.parent.onRollback = append(s.parent.onRollback, onRollback...)
+		s.parent.mu.Unlock()
+		return
+	}
+
+	for _, fn := range onCommit {
+		fn()
+	}
+}
+
+func (s *txState) rolledBack() {
+	s.mu.Lock()
+	onRollback := s.onRollback
+	s.onCommit, s.onRollback = nil, nil
+	s.mu.Unlock()
+
+	for _, fn := range onRollback {
+		fn()
+	}
+}
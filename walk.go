@@ -0,0 +1,232 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const defaultWalkChunkSize = 1000
+
+// CheckpointStore keeps the last processed key of a named walk, see WalkParams.
+type CheckpointStore interface {
+	Load(ctx context.Context, name string) (key string, found bool, err error)
+	Save(ctx context.Context, name string, key string) error
+}
+
+type WalkParams struct {
+	// Name identifies the walk in Checkpoints
+	Name string
+	// PKColumn is the ordered key column, the single PKColumnMap key of the item by default
+	PKColumn string
+	// ChunkSize is 1000 by default
+	ChunkSize int
+
+	Conditions           map[string]any
+	ConditionExpressions map[string][]any
+	Columns              []string
+
+	// Checkpoints saves the key of the last processed row after every chunk and gives it back on restart
+	Checkpoints CheckpointStore
+	// Throttle is the pause between chunks
+	Throttle time.Duration
+	// TransactionManager runs every chunk (query, fn and checkpoint) in its own TxFn,
+	// it should be the store's manager so the chunk query runs in the transaction too
+	TransactionManager *TransactionManager
+}
+
+// WalkPKRange walks the table in ascending key ranges (WHERE pk > last ORDER BY pk LIMIT n)
+// and calls fn with every chunk. Unlike OFFSET or a long-lived cursor, every chunk is a short
+// independent query, so rows changed by fn do not shift the walk and it can resume from a checkpoint.
+func (s *ModelStore) WalkPKRange(ctx context.Context, params WalkParams, itemConstructor func() ListModelI, fn func(ctx context.Context, items []ListModelI) error) error {
+	if params.Checkpoints != nil && params.Name == "" {
+		return fmt.Errorf("name is required to save checkpoints")
+	}
+	if params.ChunkSize <= 0 {
+		params.ChunkSize = defaultWalkChunkSize
+	}
+
+	listItemInstance := itemConstructor()
+
+	if params.PKColumn == "" {
+		pkModel, ok := listItemInstance.(interface{ PKColumnMap() map[string]any })
+		if !ok || len(pkModel.PKColumnMap()) != 1 {
+			return fmt.Errorf("pk column is required for items without a single column PKColumnMap")
+		}
+		for colName := range pkModel.PKColumnMap() {
+			params.PKColumn = colName
+		}
+	}
+
+	var lastKey string
+	var hasLastKey bool
+	if params.Checkpoints != nil {
+		var err error
+		lastKey, hasLastKey, err = params.Checkpoints.Load(ctx, params.Name)
+		if err != nil {
+			return fmt.Errorf("fail to load checkpoint: %w", err)
+		}
+	}
+
+	for first := true; ; first = false {
+		if !first && params.Throttle > 0 {
+			if err := sleepContext(ctx, params.Throttle); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var count int
+		var nextKey string
+
+		chunkFn := func(ctx context.Context) error {
+			items, err := s.walkChunk(ctx, params, lastKey, hasLastKey, itemConstructor)
+			if err != nil {
+				return err
+			}
+
+			count = len(items)
+			if count == 0 {
+				return nil
+			}
+
+			err = fn(ctx, items)
+			if err != nil {
+				return fmt.Errorf("chunk function: %w", err)
+			}
+
+			key, err := cursorValue(items[count-1].ListColumnMap()[params.PKColumn])
+			if err != nil {
+				return fmt.Errorf("pk column %q: %w", params.PKColumn, err)
+			}
+
+			if params.Checkpoints != nil {
+				err = params.Checkpoints.Save(ctx, params.Name, key)
+				if err != nil {
+					return fmt.Errorf("fail to save checkpoint: %w", err)
+				}
+			}
+
+			nextKey = key
+
+			return nil
+		}
+
+		var err error
+		if params.TransactionManager != nil {
+			err = params.TransactionManager.TxFn(ctx, chunkFn)
+		} else {
+			err = chunkFn(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		// advance only after the commit, a retried or failed chunk is processed again
+		if count > 0 {
+			lastKey, hasLastKey = nextKey, true
+		}
+
+		if count < params.ChunkSize {
+			return nil
+		}
+	}
+}
+
+func (s *ModelStore) walkChunk(ctx context.Context, params WalkParams, lastKey string, hasLastKey bool, itemConstructor func() ListModelI) ([]ListModelI, error) {
+	con, err := s.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listItemInstance := itemConstructor()
+
+	queryBuilder, colNames, err := s.buildListBase(ListParams{
+		Conditions:           params.Conditions,
+		ConditionExpressions: params.ConditionExpressions,
+		Columns:              params.Columns,
+	}, listItemInstance)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := listItemInstance.ListColumnMap()[params.PKColumn]; !ok {
+		return nil, fmt.Errorf("pk column %q is not in the list columns", params.PKColumn)
+	}
+	if !slices.Contains(colNames, params.PKColumn) {
+		colNames = append(colNames, params.PKColumn)
+	}
+
+	// the key is sent in text format, like cursor values
+	if hasLastKey {
+		queryBuilder = queryBuilder.Where(params.PKColumn+` > ?`, lastKey)
+	}
+
+	query, args, err := queryBuilder.
+		Columns(colNames...).
+		OrderBy(params.PKColumn).
+		Limit(uint64(params.ChunkSize)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("fail to build query: %w", err)
+	}
+
+	rows, err := con.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	items := make([]ListModelI, 0, params.ChunkSize)
+	for rows.Next() {
+		m := itemConstructor()
+
+		err = rows.Scan(fieldPointersForColNames(m, colNames)...)
+		if err != nil {
+			return nil, fmt.Errorf("fail to scan: %w", err)
+		}
+
+		items = append(items, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return items, nil
+}
+
+// MemoryCheckpointStore is a CheckpointStore for tests and jobs that resume within one process.
+// Inside a transaction Save takes effect on commit.
+type MemoryCheckpointStore struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{
+		keys: map[string]string{},
+	}
+}
+
+func (s *MemoryCheckpointStore) Load(_ context.Context, name string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[name]
+
+	return key, ok, nil
+}
+
+func (s *MemoryCheckpointStore) Save(ctx context.Context, name string, key string) error {
+	OnCommit(ctx, func() {
+		s.mu.Lock()
+		s.keys[name] = key
+		s.mu.Unlock()
+	})
+
+	return nil
+}
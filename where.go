@@ -0,0 +1,127 @@
+package mobone
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type WhereParams struct {
+	Conditions           map[string]any
+	ConditionExpressions map[string][]any
+	// AllowEmpty permits touching every row of the table when there are no conditions
+	AllowEmpty bool
+	// Returning, if set, receives the RETURNING rows the same way as the itemConstructor of List
+	Returning func(add bool) ListModelI
+	// ReturningColumns limits the returned columns, all of Returning's ListColumnMap by default
+	ReturningColumns []string
+}
+
+func (p WhereParams) empty() bool {
+	return len(p.Conditions) == 0 && len(p.ConditionExpressions) == 0
+}
+
+// UpdateWhere sets setMap on every row matching params and returns the number of updated rows.
+func (s *ModelStore) UpdateWhere(ctx context.Context, setMap map[string]any, params WhereParams) (int64, error) {
+	if params.empty() && !params.AllowEmpty {
+		return 0, ErrEmptyConditions
+	}
+
+	queryBuilder := s.QB.Update(s.TableName).SetMap(setMap)
+	for _, pred := range conditionPredicates(params.Conditions, params.ConditionExpressions) {
+		queryBuilder = queryBuilder.Where(pred)
+	}
+
+	colNames, err := whereReturningColumnNames(params)
+	if err != nil {
+		return 0, err
+	}
+	if len(colNames) > 0 {
+		queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(colNames, ","))
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("fail to build query: %w", err)
+	}
+
+	return s.execWhere(ctx, query, args, colNames, params.Returning)
+}
+
+// DeleteWhere deletes every row matching params and returns the number of deleted rows.
+func (s *ModelStore) DeleteWhere(ctx context.Context, params WhereParams) (int64, error) {
+	if params.empty() && !params.AllowEmpty {
+		return 0, ErrEmptyConditions
+	}
+
+	queryBuilder := s.QB.Delete(s.TableName)
+	for _, pred := range conditionPredicates(params.Conditions, params.ConditionExpressions) {
+		queryBuilder = queryBuilder.Where(pred)
+	}
+
+	colNames, err := whereReturningColumnNames(params)
+	if err != nil {
+		return 0, err
+	}
+	if len(colNames) > 0 {
+		queryBuilder = queryBuilder.Suffix(`RETURNING ` + strings.Join(colNames, ","))
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("fail to build query: %w", err)
+	}
+
+	return s.execWhere(ctx, query, args, colNames, params.Returning)
+}
+
+func whereReturningColumnNames(params WhereParams) ([]string, error) {
+	if params.Returning == nil {
+		return nil, nil
+	}
+
+	colNames := allowedColumnNames(params.Returning(false).ListColumnMap(), params.ReturningColumns)
+	if len(colNames) == 0 {
+		return nil, fmt.Errorf("no columns")
+	}
+
+	return colNames, nil
+}
+
+func (s *ModelStore) execWhere(ctx context.Context, query string, args []any, colNames []string, itemConstructor func(add bool) ListModelI) (int64, error) {
+	con, err := s.connection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(colNames) == 0 {
+		tag, err := con.Exec(ctx, query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("fail to exec: %w", classifyError(err))
+		}
+
+		return tag.RowsAffected(), nil
+	}
+
+	rows, err := con.Query(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("fail to query: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var rowsAffected int64
+	for rows.Next() {
+		m := itemConstructor(true)
+
+		err = rows.Scan(fieldPointersForColNames(m, colNames)...)
+		if err != nil {
+			return rowsAffected, fmt.Errorf("fail to scan: %w", err)
+		}
+		rowsAffected++
+	}
+	if err = rows.Err(); err != nil {
+		return rowsAffected, fmt.Errorf("rows.Err: %w", classifyError(err))
+	}
+
+	return rowsAffected, nil
+}